@@ -0,0 +1,108 @@
+// Package accesslog logs the requests served by the API.
+package accesslog
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Redacted replaces the values of sensitive headers and query parameters.
+const Redacted = "REDACTED"
+
+// Headers and query parameters always redacted.
+var (
+	DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+	DefaultRedactedQuery   = []string{"access_token", "api_key", "key", "password", "secret", "token"}
+)
+
+// Config configures the access log.
+type Config struct {
+	// Sample logs one successful request out of Sample, zero logs none of
+	// them. Failed requests are always logged.
+	Sample uint32
+	// RedactedHeaders and RedactedQuery are redacted on top of the default
+	// ones.
+	RedactedHeaders []string
+	RedactedQuery   []string
+}
+
+// Middleware logs every request once served, with the context logger of the
+// request, see requestid.Middleware. Server errors are logged at error
+// level, client errors at warn level and the rest at info level.
+func Middleware(cfg Config) gin.HandlerFunc {
+	headers := make(map[string]bool)
+	for _, h := range append(DefaultRedactedHeaders, cfg.RedactedHeaders...) {
+		headers[http.CanonicalHeaderKey(h)] = true
+	}
+
+	query := make(map[string]bool)
+	for _, q := range append(DefaultRedactedQuery, cfg.RedactedQuery...) {
+		query[strings.ToLower(q)] = true
+	}
+
+	var sampler zerolog.Sampler = &zerolog.BasicSampler{N: cfg.Sample}
+
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := zerolog.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zerolog.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zerolog.WarnLevel
+		case cfg.Sample == 0 || !sampler.Sample(level):
+			return
+		}
+
+		hdr := zerolog.Dict()
+		for name, values := range ctx.Request.Header {
+			if headers[name] {
+				hdr.Str(name, Redacted)
+			} else {
+				hdr.Str(name, strings.Join(values, ", "))
+			}
+		}
+
+		log.Ctx(ctx.Request.Context()).WithLevel(level).
+			Str("path", ctx.Request.URL.Path).
+			Str("query", redactQuery(ctx.Request.URL.RawQuery, query)).
+			Dict("headers", hdr).
+			Int("status", status).
+			Int("size", ctx.Writer.Size()).
+			Dur("latency", time.Since(start)).
+			Str("client_ip", ctx.ClientIP()).
+			Msg("request served")
+	}
+}
+
+// redactQuery replaces the values of the sensitive parameters of a raw query.
+func redactQuery(raw string, sensitive map[string]bool) string {
+	if raw == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		// an unparsable query may hide anything
+		return Redacted
+	}
+
+	for name, vals := range values {
+		if sensitive[strings.ToLower(name)] {
+			for i := range vals {
+				vals[i] = Redacted
+			}
+		}
+	}
+
+	return values.Encode()
+}
@@ -0,0 +1,98 @@
+package accesslog_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/accesslog"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(cfg accesslog.Config, status int, target string, headers map[string]string) []string {
+		buf := new(bytes.Buffer)
+		logger := zerolog.New(buf)
+
+		router := gin.New()
+		router.Use(accesslog.Middleware(cfg))
+		router.GET("/todo", func(ctx *gin.Context) {
+			ctx.String(status, "test")
+		})
+
+		req := httptest.NewRequest("GET", target, http.NoBody)
+		for name, val := range headers {
+			req.Header.Set(name, val)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req.WithContext(logger.WithContext(req.Context())))
+
+		return strings.Split(strings.TrimSpace(buf.String()), "\n")
+	}
+
+	t.Run("logs requests", func(t *testing.T) {
+		lines := serve(accesslog.Config{Sample: 1}, http.StatusOK, "/todo?id=1", map[string]string{"User-Agent": "test"})
+
+		assert.Len(t, lines, 1)
+		assert.Equal(t, "info", gjson.Get(lines[0], "level").String())
+		assert.Equal(t, "/todo", gjson.Get(lines[0], "path").String())
+		assert.Equal(t, "id=1", gjson.Get(lines[0], "query").String())
+		assert.Equal(t, "test", gjson.Get(lines[0], "headers.User-Agent").String())
+		assert.Equal(t, int64(http.StatusOK), gjson.Get(lines[0], "status").Int())
+		assert.Equal(t, int64(4), gjson.Get(lines[0], "size").Int())
+		assert.True(t, gjson.Get(lines[0], "latency").Exists())
+	})
+
+	t.Run("logs failures by severity", func(t *testing.T) {
+		lines := serve(accesslog.Config{}, http.StatusNotFound, "/todo", nil)
+		assert.Equal(t, "warn", gjson.Get(lines[0], "level").String())
+
+		lines = serve(accesslog.Config{}, http.StatusInternalServerError, "/todo", nil)
+		assert.Equal(t, "error", gjson.Get(lines[0], "level").String())
+	})
+
+	t.Run("samples successful requests", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		logger := zerolog.New(buf)
+
+		router := gin.New()
+		router.Use(accesslog.Middleware(accesslog.Config{Sample: 3}))
+		router.GET("/todo", func(ctx *gin.Context) {})
+
+		for i := 0; i < 6; i++ {
+			req := httptest.NewRequest("GET", "/todo", http.NoBody)
+			router.ServeHTTP(httptest.NewRecorder(), req.WithContext(logger.WithContext(req.Context())))
+		}
+
+		assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	})
+
+	t.Run("skips successful requests without sampling", func(t *testing.T) {
+		lines := serve(accesslog.Config{}, http.StatusOK, "/todo", nil)
+		assert.Equal(t, []string{""}, lines)
+	})
+
+	t.Run("redacts credentials", func(t *testing.T) {
+		cfg := accesslog.Config{
+			Sample:          1,
+			RedactedHeaders: []string{"x-secret"},
+			RedactedQuery:   []string{"Code"},
+		}
+		lines := serve(cfg, http.StatusOK, "/todo?id=1&token=abc&code=def", map[string]string{
+			"Authorization": "Bearer abc",
+			"X-Secret":      "def",
+		})
+
+		assert.Equal(t, "code=REDACTED&id=1&token=REDACTED", gjson.Get(lines[0], "query").String())
+		assert.Equal(t, accesslog.Redacted, gjson.Get(lines[0], "headers.Authorization").String())
+		assert.Equal(t, accesslog.Redacted, gjson.Get(lines[0], "headers.X-Secret").String())
+		assert.NotContains(t, lines[0], "abc")
+		assert.NotContains(t, lines[0], "def")
+	})
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type TodoRepo interface {
+	ArchiveTodos(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Archiver periodically archives the todos completed for too long.
+type Archiver struct {
+	repo     TodoRepo
+	after    time.Duration
+	interval time.Duration
+}
+
+// NewArchiver instantiates Archiver. Todos completed for longer than after are
+// archived every interval.
+func NewArchiver(repo TodoRepo, after, interval time.Duration) Archiver {
+	return Archiver{
+		repo:     repo,
+		after:    after,
+		interval: interval,
+	}
+}
+
+// Run archives todos until the given context is closed.
+func (a Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		a.archive(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a Archiver) archive(ctx context.Context) {
+	n, err := a.repo.ArchiveTodos(ctx, time.Now().Add(-a.after))
+	if err != nil {
+		log.Error().Err(err).Msg("could not archive todos")
+		return
+	}
+
+	log.Debug().Int64("count", n).Msg("archived todos")
+}
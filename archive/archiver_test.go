@@ -0,0 +1,66 @@
+package archive_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubRepo struct {
+	mu     sync.Mutex
+	before []time.Time
+	err    error
+}
+
+func (sr *stubRepo) ArchiveTodos(ctx context.Context, before time.Time) (int64, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.before = append(sr.before, before)
+	return 1, sr.err
+}
+
+func (sr *stubRepo) calls() []time.Time {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return append([]time.Time(nil), sr.before...)
+}
+
+func TestArchiver(t *testing.T) {
+	t.Run("archives todos completed before the configured period", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		repo := &stubRepo{}
+
+		done := make(chan struct{})
+		go func() {
+			archive.NewArchiver(repo, 24*time.Hour, 10*time.Millisecond).Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool { return len(repo.calls()) >= 2 }, time.Second, 5*time.Millisecond)
+		cancel()
+		<-done
+
+		before := repo.calls()[0]
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Second)
+	})
+
+	t.Run("keeps running on repo error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		repo := &stubRepo{err: errors.New("test")}
+
+		done := make(chan struct{})
+		go func() {
+			archive.NewArchiver(repo, time.Hour, 10*time.Millisecond).Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool { return len(repo.calls()) >= 2 }, time.Second, 5*time.Millisecond)
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/tenant"
+)
+
+const (
+	// apiKeyMarker starts every API key, telling them apart from other
+	// bearer tokens.
+	apiKeyMarker = "tdk_"
+	// apiKeyPrefixLen is the length of the API key prefix kept in clear to
+	// identify them.
+	apiKeyPrefixLen = len(apiKeyMarker) + 8
+)
+
+type APIKeyRepo interface {
+	UseAPIKey(ctx context.Context, keyHash []byte) (model.APIKey, error)
+}
+
+// NewAPIKey generates a random API key and returns it with its prefix.
+func NewAPIKey() (key, prefix string, err error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	key = apiKeyMarker + token
+
+	return key, key[:apiKeyPrefixLen], nil
+}
+
+// APIKeyAuthenticator authenticates requests bearing an API key.
+type APIKeyAuthenticator struct {
+	repo APIKeyRepo
+}
+
+// NewAPIKeyAuthenticator instantiates APIKeyAuthenticator.
+func NewAPIKeyAuthenticator(repo APIKeyRepo) APIKeyAuthenticator {
+	return APIKeyAuthenticator{
+		repo: repo,
+	}
+}
+
+// Authenticate implements Authenticator.
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key, ok := BearerToken(r)
+	if !ok || !strings.HasPrefix(key, apiKeyMarker) {
+		return Principal{}, ErrNoCredentials
+	}
+
+	res, err := a.repo.UseAPIKey(r.Context(), HashToken(key))
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		return Principal{}, fmt.Errorf("%w: unknown, expired or revoked API key", ErrInvalidCredentials)
+	case err != nil:
+		return Principal{}, fmt.Errorf("could not get API key: %w", err)
+	}
+
+	// API keys are looked up in the organization of the request only
+	org, _ := tenant.OrgFrom(r.Context())
+
+	return Principal{
+		UserID: res.OwnerID,
+		// never nil, an API key without scope is granted nothing
+		Scopes:   append([]string{}, res.Scopes...),
+		Org:      org,
+		APIKeyID: res.ID,
+	}, nil
+}
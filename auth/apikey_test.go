@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAPIKeyRepo struct {
+	key     model.APIKey
+	err     error
+	keyHash []byte
+}
+
+func (sr *stubAPIKeyRepo) UseAPIKey(ctx context.Context, keyHash []byte) (model.APIKey, error) {
+	sr.keyHash = keyHash
+	return sr.key, sr.err
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, err := auth.NewAPIKey()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^tdk_[A-Za-z0-9_-]{43}$`, key)
+	assert.Len(t, prefix, 12)
+	assert.Equal(t, key[:12], prefix)
+
+	other, _, err := auth.NewAPIKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	t.Run("authenticates a valid API key", func(t *testing.T) {
+		owner, id := uuid.New(), uuid.New()
+		repo := &stubAPIKeyRepo{key: model.APIKey{ID: id, OwnerID: owner, Scopes: []string{auth.ScopeTodoRead}}}
+
+		org := uuid.New()
+		req := bearerRequest("tdk_key")
+		req = req.WithContext(tenant.WithOrg(req.Context(), org))
+
+		p, err := auth.NewAPIKeyAuthenticator(repo).Authenticate(req)
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{UserID: owner, Scopes: []string{auth.ScopeTodoRead}, Org: org, APIKeyID: id}, p)
+		assert.Equal(t, auth.HashToken("tdk_key"), repo.keyHash)
+	})
+
+	t.Run("restricts API keys without scope to nothing", func(t *testing.T) {
+		repo := &stubAPIKeyRepo{key: model.APIKey{OwnerID: uuid.New()}}
+
+		p, err := auth.NewAPIKeyAuthenticator(repo).Authenticate(bearerRequest("tdk_key"))
+		assert.NoError(t, err)
+		assert.False(t, p.HasScope(auth.ScopeTodoRead))
+	})
+
+	t.Run("no credentials on other bearer tokens", func(t *testing.T) {
+		_, err := auth.NewAPIKeyAuthenticator(&stubAPIKeyRepo{}).Authenticate(bearerRequest("token"))
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+
+	t.Run("invalid credentials on unknown API key", func(t *testing.T) {
+		repo := &stubAPIKeyRepo{err: repository.ErrAPIKeyNotFound}
+
+		_, err := auth.NewAPIKeyAuthenticator(repo).Authenticate(bearerRequest("tdk_key"))
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("error on repo error", func(t *testing.T) {
+		repo := &stubAPIKeyRepo{err: errors.New("test")}
+
+		_, err := auth.NewAPIKeyAuthenticator(repo).Authenticate(bearerRequest("tdk_key"))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Client certificate fields matched by CertRule.
+const (
+	CertFieldCN    = "cn"
+	CertFieldDNS   = "dns"
+	CertFieldURI   = "uri"
+	CertFieldEmail = "email"
+)
+
+// certIssuer namespaces the user IDs of client certificates, see SubjectID.
+const certIssuer = "x509"
+
+// CertRule maps client certificates to a principal restricted to Scopes.
+type CertRule struct {
+	// Field is the certificate field matched: the subject common name, or
+	// a DNS, URI or email subject alternative name.
+	Field string
+	// Pattern is matched against the field, see path.Match.
+	Pattern string
+	Scopes  []string
+}
+
+// ParseCertRules parses semicolon separated rules such as
+// "uri:spiffe://example.org/billing/*=todo:read todo:write", made of the field,
+// the pattern and the space separated scopes.
+func ParseCertRules(rules string) ([]CertRule, error) {
+	var res []CertRule
+	for _, rule := range strings.Split(rules, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		field, rest, ok := strings.Cut(rule, ":")
+		if !ok {
+			return nil, fmt.Errorf("missing field in certificate rule: %s", rule)
+		}
+		i := strings.LastIndex(rest, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("missing scopes in certificate rule: %s", rule)
+		}
+
+		r := CertRule{
+			Field:   strings.ToLower(field),
+			Pattern: rest[:i],
+			Scopes:  strings.Fields(rest[i+1:]),
+		}
+		switch r.Field {
+		case CertFieldCN, CertFieldDNS, CertFieldURI, CertFieldEmail:
+		default:
+			return nil, fmt.Errorf("unknown field in certificate rule: %s", rule)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil || r.Pattern == "" {
+			return nil, fmt.Errorf("invalid pattern in certificate rule: %s", rule)
+		}
+		if len(r.Scopes) == 0 {
+			return nil, fmt.Errorf("missing scopes in certificate rule: %s", rule)
+		}
+
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+// match returns the value of the certificate field matching the rule.
+func (r CertRule) match(cert *x509.Certificate) (string, bool) {
+	var values []string
+	switch r.Field {
+	case CertFieldCN:
+		values = []string{cert.Subject.CommonName}
+	case CertFieldDNS:
+		values = cert.DNSNames
+	case CertFieldURI:
+		for _, u := range cert.URIs {
+			values = append(values, u.String())
+		}
+	case CertFieldEmail:
+		values = cert.EmailAddresses
+	}
+
+	for _, val := range values {
+		if ok, _ := path.Match(r.Pattern, val); ok && val != "" {
+			return val, true
+		}
+	}
+
+	return "", false
+}
+
+// CertAuthenticator authenticates requests made over a TLS connection with a
+// client certificate verified against the client CAs of the server. The
+// certificate is mapped to a principal by the first rule it matches.
+type CertAuthenticator struct {
+	rules []CertRule
+}
+
+// NewCertAuthenticator instantiates CertAuthenticator.
+func NewCertAuthenticator(rules []CertRule) CertAuthenticator {
+	return CertAuthenticator{
+		rules: rules,
+	}
+}
+
+// Authenticate implements Authenticator. The user ID of the principal is
+// derived from the matched field, see SubjectID.
+func (a CertAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Principal{}, ErrNoCredentials
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+
+	for _, rule := range a.rules {
+		val, ok := rule.match(cert)
+		if !ok {
+			continue
+		}
+
+		p := Principal{
+			UserID: SubjectID(certIssuer, rule.Field+":"+val),
+			Scopes: append([]string{}, rule.Scopes...),
+		}
+		if rule.Field == CertFieldEmail {
+			p.Email = val
+		}
+
+		return p, nil
+	}
+
+	// the certificate may be the one of a proxy, authenticating its callers
+	// with bearer tokens
+	return Principal{}, fmt.Errorf("%w: no rule matches certificate %s", ErrNoCredentials, cert.Subject)
+}
@@ -0,0 +1,94 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certRequest returns a request made over a TLS connection with the given
+// verified client certificate.
+func certRequest(cert *x509.Certificate) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return r
+}
+
+func TestParseCertRules(t *testing.T) {
+	t.Run("parses rules", func(t *testing.T) {
+		rules, err := auth.ParseCertRules("uri:spiffe://example.org/billing/*=todo:read todo:write; CN:reporting=todo:read;")
+		assert.NoError(t, err)
+		assert.Equal(t, []auth.CertRule{
+			{Field: auth.CertFieldURI, Pattern: "spiffe://example.org/billing/*", Scopes: []string{auth.ScopeTodoRead, auth.ScopeTodoWrite}},
+			{Field: auth.CertFieldCN, Pattern: "reporting", Scopes: []string{auth.ScopeTodoRead}},
+		}, rules)
+	})
+
+	t.Run("parses no rules", func(t *testing.T) {
+		rules, err := auth.ParseCertRules("")
+		assert.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	for name, rules := range map[string]string{
+		"missing field":   "reporting=todo:read",
+		"unknown field":   "ou:reporting=todo:read",
+		"missing scopes":  "cn:reporting",
+		"empty scopes":    "cn:reporting=",
+		"invalid pattern": "cn:[=todo:read",
+		"empty pattern":   "cn:=todo:read",
+	} {
+		t.Run("error on "+name, func(t *testing.T) {
+			_, err := auth.ParseCertRules(rules)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCertAuthenticator(t *testing.T) {
+	rules, err := auth.ParseCertRules("uri:spiffe://example.org/billing/*=todo:read todo:write;email:*@example.org=todo:read")
+	require.NoError(t, err)
+	SUT := auth.NewCertAuthenticator(rules)
+
+	billing, err := url.Parse("spiffe://example.org/billing/api")
+	require.NoError(t, err)
+
+	t.Run("authenticates a certificate matching a rule", func(t *testing.T) {
+		p, err := SUT.Authenticate(certRequest(&x509.Certificate{URIs: []*url.URL{billing}}))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{
+			UserID: auth.SubjectID("x509", "uri:spiffe://example.org/billing/api"),
+			Scopes: []string{auth.ScopeTodoRead, auth.ScopeTodoWrite},
+		}, p)
+	})
+
+	t.Run("maps email addresses", func(t *testing.T) {
+		p, err := SUT.Authenticate(certRequest(&x509.Certificate{EmailAddresses: []string{"ops@example.org"}}))
+		assert.NoError(t, err)
+		assert.Equal(t, "ops@example.org", p.Email)
+		assert.Equal(t, []string{auth.ScopeTodoRead}, p.Scopes)
+	})
+
+	t.Run("no credentials on a certificate matching no rule", func(t *testing.T) {
+		_, err := SUT.Authenticate(certRequest(&x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}))
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+
+	t.Run("no credentials without verified certificate", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		_, err := SUT.Authenticate(r)
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+
+		r.TLS = &tls.ConnectionState{}
+		_, err = SUT.Authenticate(r)
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+}
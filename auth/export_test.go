@@ -0,0 +1,11 @@
+package auth
+
+import "time"
+
+// SetOIDCRefreshInterval overrides the minimum delay between two OIDC
+// provider refreshes and returns a function restoring it.
+func SetOIDCRefreshInterval(d time.Duration) func() {
+	prev := oidcRefreshInterval
+	oidcRefreshInterval = d
+	return func() { oidcRefreshInterval = prev }
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// jwtLeeway is the clock skew tolerated when checking exp and nbf.
+const jwtLeeway = 30 * time.Second
+
+// jwtMethods are the accepted signing algorithms.
+var jwtMethods = []string{"HS256", "RS256", "EdDSA"}
+
+// KeySource resolves the keys verifying token signatures.
+type KeySource interface {
+	// Keys returns the keys matching the given key ID.
+	Keys(kid string) []Key
+}
+
+// ClaimMapping names the token claims mapped onto the principal. Nested
+// claims are named with a dot separated path.
+type ClaimMapping struct {
+	Email  string
+	Groups string
+	// Org holds the ID of the organization of the principal.
+	Org string
+}
+
+// JWTAuthenticator authenticates requests bearing a JSON Web Token signed with
+// a key of its key source.
+type JWTAuthenticator struct {
+	keys    KeySource
+	parser  *jwt.Parser
+	issuer  string
+	mapping ClaimMapping
+}
+
+// NewJWTAuthenticator instantiates JWTAuthenticator. Tokens must expire and,
+// when not empty, be issued by issuer for audience. Claims not named by
+// mapping default to "email", "groups" and "org_id".
+func NewJWTAuthenticator(keys KeySource, issuer, audience string, mapping ClaimMapping) JWTAuthenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	if mapping.Email == "" {
+		mapping.Email = "email"
+	}
+	if mapping.Groups == "" {
+		mapping.Groups = "groups"
+	}
+	if mapping.Org == "" {
+		mapping.Org = "org_id"
+	}
+
+	return JWTAuthenticator{
+		keys:    keys,
+		parser:  jwt.NewParser(opts...),
+		issuer:  issuer,
+		mapping: mapping,
+	}
+}
+
+// Authenticate implements Authenticator. Tokens from other issuers than the
+// expected one are left to other authenticators.
+func (a JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return Principal{}, ErrNoCredentials
+	}
+
+	if a.issuer != "" {
+		var unverified jwt.RegisteredClaims
+		_, _, err := a.parser.ParseUnverified(token, &unverified)
+		if err == nil && unverified.Issuer != "" && unverified.Issuer != a.issuer {
+			return Principal{}, ErrNoCredentials
+		}
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keyFunc); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return Principal{}, fmt.Errorf("%w: missing subject", ErrInvalidCredentials)
+	}
+	iss, _ := claims.GetIssuer()
+
+	p := Principal{
+		UserID: SubjectID(iss, sub),
+	}
+	p.Email, _ = claim(claims, a.mapping.Email).(string)
+	p.Groups = stringList(claim(claims, a.mapping.Groups))
+	if org, ok := claim(claims, a.mapping.Org).(string); ok {
+		var err error
+		if p.Org, err = uuid.Parse(org); err != nil {
+			return Principal{}, fmt.Errorf("%w: invalid organization: %v", ErrInvalidCredentials, err)
+		}
+	}
+
+	// scope is the space-delimited list of scopes the token is restricted
+	// to, see RFC 8693 section 4.2
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = append([]string{}, strings.Fields(scope)...)
+	}
+
+	return p, nil
+}
+
+func (a JWTAuthenticator) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var set jwt.VerificationKeySet
+	for _, k := range a.keys.Keys(kid) {
+		if keyMatches(token.Method, k.Key) {
+			set.Keys = append(set.Keys, k.Key)
+		}
+	}
+
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("%w for key ID %q and algorithm %s", ErrNoKey, kid, token.Method.Alg())
+	}
+
+	return set, nil
+}
+
+// keyMatches reports whether key can verify signatures of the given method.
+func keyMatches(method jwt.SigningMethod, key any) bool {
+	switch key.(type) {
+	case []byte:
+		_, ok := method.(*jwt.SigningMethodHMAC)
+		return ok
+	case *rsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodRSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	default:
+		return false
+	}
+}
+
+// claim returns the value of a possibly nested claim.
+func claim(claims map[string]any, path string) any {
+	var val any = claims
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := val.(map[string]any)
+		if !ok {
+			return nil
+		}
+		val = obj[name]
+	}
+
+	return val
+}
+
+// stringList returns the strings of a claim being either a string or an array.
+func stringList(val any) []string {
+	switch val := val.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		var res []string
+		for _, v := range val {
+			if s, ok := v.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+// SubjectID returns the user ID a token subject stands for: the subject
+// itself when it is a UUID, otherwise a UUID derived from the issuer and
+// subject so that it is stable across tokens.
+func SubjectID(issuer, subject string) uuid.UUID {
+	if id, err := uuid.Parse(subject); err == nil {
+		return id
+	}
+
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(issuer+"#"+subject))
+}
@@ -0,0 +1,185 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bearerRequest(token string) *http.Request {
+	req, _ := http.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	res, err := token.SignedString(key)
+	require.NoError(t, err)
+	return res
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("secret")
+
+	ks := auth.NewKeySet()
+	require.NoError(t, ks.AddSecretFile(writeFile(t, "secret", secret)))
+	require.NoError(t, ks.AddJWKSFile(writeFile(t, "jwks.json", []byte(fmt.Sprintf(`{"keys": [%s, %s]}`,
+		rsaJWK("rs", &rsaKey.PublicKey), ed25519JWK("ed", edPub))))))
+
+	SUT := auth.NewJWTAuthenticator(ks, "https://issuer.example.com", "todo", auth.ClaimMapping{})
+
+	owner := uuid.New()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		res := jwt.MapClaims{
+			"sub":   owner.String(),
+			"iss":   "https://issuer.example.com",
+			"aud":   "todo",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nbf":   time.Now().Add(-time.Minute).Unix(),
+			"email": "john@example.com",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(res, k)
+			} else {
+				res[k] = v
+			}
+		}
+		return res
+	}
+
+	for name, token := range map[string]string{
+		"HS256": sign(t, jwt.SigningMethodHS256, "", secret, claims(nil)),
+		"RS256": sign(t, jwt.SigningMethodRS256, "rs", rsaKey, claims(nil)),
+		"EdDSA": sign(t, jwt.SigningMethodEdDSA, "ed", edKey, claims(nil)),
+	} {
+		token := token
+		t.Run("authenticates "+name+" tokens", func(t *testing.T) {
+			p, err := SUT.Authenticate(bearerRequest(token))
+			assert.NoError(t, err)
+			assert.Equal(t, auth.Principal{UserID: owner, Email: "john@example.com"}, p)
+		})
+	}
+
+	t.Run("maps non UUID subjects to a stable owner", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"sub": "john"}))
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, auth.SubjectID("https://issuer.example.com", "john"), p.UserID)
+		assert.NotEqual(t, auth.SubjectID("https://other.example.com", "john"), p.UserID)
+	})
+
+	t.Run("restricts tokens with scope claim", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"scope": "todo:read openid"}))
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"todo:read", "openid"}, p.Scopes)
+
+		token = sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"scope": ""}))
+
+		p, err = SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.False(t, p.HasScope(auth.ScopeTodoRead))
+	})
+
+	t.Run("maps configured claims", func(t *testing.T) {
+		SUT := auth.NewJWTAuthenticator(ks, "", "", auth.ClaimMapping{Email: "upn", Groups: "realm_access.roles"})
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{
+			"upn":          "jane@example.com",
+			"realm_access": map[string]any{"roles": []string{"dev", "ops"}},
+		}))
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", p.Email)
+		assert.Equal(t, []string{"dev", "ops"}, p.Groups)
+	})
+
+	t.Run("maps default claims", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"groups": "dev"}))
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, "john@example.com", p.Email)
+		assert.Equal(t, []string{"dev"}, p.Groups)
+	})
+
+	t.Run("maps the organization claim", func(t *testing.T) {
+		org := uuid.New()
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"org_id": org.String()}))
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, org, p.Org)
+
+		token = sign(t, jwt.SigningMethodHS256, "", secret, claims(nil))
+
+		p, err = SUT.Authenticate(bearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, uuid.Nil, p.Org)
+	})
+
+	t.Run("no credentials on non JWT bearer tokens", func(t *testing.T) {
+		_, err := SUT.Authenticate(bearerRequest("token"))
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+
+	t.Run("no credentials on tokens of other issuers", func(t *testing.T) {
+		token := sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"iss": "other"}))
+
+		_, err := SUT.Authenticate(bearerRequest(token))
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"expired":            sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"without expiration": sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"exp": nil})),
+		"not yet valid":      sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"wrong audience":     sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"aud": "other"})),
+		"without issuer":     sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"iss": nil})),
+		"without subject":    sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"sub": nil})),
+		"wrong secret":       sign(t, jwt.SigningMethodHS256, "", []byte("other"), claims(nil)),
+		"unknown key":        sign(t, jwt.SigningMethodRS256, "rs", otherKey, claims(nil)),
+		"unknown key ID":     sign(t, jwt.SigningMethodRS256, "other", rsaKey, claims(nil)),
+		"other algorithm":    sign(t, jwt.SigningMethodHS512, "", secret, claims(nil)),
+		"none algorithm":     sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims(nil)),
+		"malformed":          "a.b.c",
+		"non valid org_id":   sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{"org_id": "acme"})),
+	} {
+		token := token
+		t.Run("invalid credentials on "+name+" tokens", func(t *testing.T) {
+			_, err := SUT.Authenticate(bearerRequest(token))
+			assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestSubjectID(t *testing.T) {
+	id := uuid.New()
+	assert.Equal(t, id, auth.SubjectID("issuer", id.String()))
+	assert.Equal(t, auth.SubjectID("issuer", "john"), auth.SubjectID("issuer", "john"))
+	assert.NotEqual(t, auth.SubjectID("issuer", "john"), auth.SubjectID("issuer", "jane"))
+}
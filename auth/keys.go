@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// keyReloadInterval is how often key files are checked for changes.
+const keyReloadInterval = time.Second
+
+var ErrNoKey = errors.New("no verification key")
+
+// Key is a token verification key: an HMAC secret ([]byte), an RSA public key
+// (*rsa.PublicKey) or an Ed25519 public key (ed25519.PublicKey).
+type Key struct {
+	// ID is the key ID a token refers to it with. Keys without ID verify
+	// tokens whatever their key ID.
+	ID  string
+	Key any
+}
+
+// KeySet is a set of verification keys loaded from files, reloaded when the
+// files change.
+type KeySet struct {
+	mu      sync.Mutex
+	files   []*keyFile
+	checked time.Time
+}
+
+type keyFile struct {
+	path    string
+	parse   func([]byte) ([]Key, error)
+	modTime time.Time
+	size    int64
+	keys    []Key
+}
+
+// NewKeySet instantiates an empty KeySet.
+func NewKeySet() *KeySet {
+	return new(KeySet)
+}
+
+// AddKeyFile adds a PEM encoded RSA or Ed25519 public key or certificate.
+func (ks *KeySet) AddKeyFile(path string) error {
+	return ks.add(path, parsePEM)
+}
+
+// AddSecretFile adds an HMAC secret, made of the whole file content.
+func (ks *KeySet) AddSecretFile(path string) error {
+	return ks.add(path, parseSecret)
+}
+
+// AddJWKSFile adds the signature keys of a JSON Web Key Set (RFC 7517).
+func (ks *KeySet) AddJWKSFile(path string) error {
+	return ks.add(path, parseJWKS)
+}
+
+func (ks *KeySet) add(path string, parse func([]byte) ([]Key, error)) error {
+	f := &keyFile{path: path, parse: parse}
+	if _, err := f.reload(); err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.files = append(ks.files, f)
+
+	return nil
+}
+
+// Keys returns the keys matching the given key ID.
+func (ks *KeySet) Keys(kid string) []Key {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if time.Since(ks.checked) >= keyReloadInterval {
+		ks.reload()
+	}
+
+	var res []Key
+	for _, f := range ks.files {
+		res = append(res, matchKeys(f.keys, kid)...)
+	}
+
+	return res
+}
+
+// Reload reloads the key files that changed. Files that can no longer be read
+// or parsed keep their previous keys.
+func (ks *KeySet) Reload() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.reload()
+}
+
+func (ks *KeySet) reload() {
+	ks.checked = time.Now()
+
+	for _, f := range ks.files {
+		changed, err := f.reload()
+		switch {
+		case err != nil:
+			log.Error().Err(err).Str("path", f.path).Msg("could not reload key file")
+		case changed:
+			log.Info().Str("path", f.path).Int("keys", len(f.keys)).Msg("reloaded key file")
+		}
+	}
+}
+
+// reload reads and parses the file if it changed since the last time.
+func (f *keyFile) reload() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false, fmt.Errorf("could not stat key file: %w", err)
+	}
+
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, fmt.Errorf("could not read key file: %w", err)
+	}
+
+	keys, err := f.parse(data)
+	if err != nil {
+		return false, fmt.Errorf("could not parse key file %s: %w", f.path, err)
+	}
+
+	f.keys, f.modTime, f.size = keys, info.ModTime(), info.Size()
+
+	return true, nil
+}
+
+// matchKeys returns the keys verifying tokens with the given key ID.
+func matchKeys(keys []Key, kid string) []Key {
+	var res []Key
+	for _, k := range keys {
+		if k.ID == "" || kid == "" || k.ID == kid {
+			res = append(res, k)
+		}
+	}
+
+	return res
+}
+
+func parseSecret(data []byte) ([]Key, error) {
+	secret := bytes.TrimSpace(data)
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+
+	return []Key{{Key: secret}}, nil
+}
+
+func parsePEM(data []byte) ([]Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return []Key{{Key: key}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", key)
+	}
+}
+
+// jwk is a JSON Web Key, see RFC 7517, RFC 7518 and RFC 8037.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	// oct
+	K string `json:"k"`
+}
+
+func parseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var res []Key
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.key()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			res = append(res, Key{ID: k.Kid, Key: key})
+		}
+	}
+
+	return res, nil
+}
+
+// key returns the verification key, or nil if its type is not supported.
+func (k jwk) key() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch {
+	case k.Kty == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 || exp.Int64() < 3 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "oct":
+		secret, err := decode(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid secret")
+		}
+		return secret, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,147 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes data into a new file of the test temporary directory.
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// rewriteFile replaces the content of a file, making sure its modification
+// time changes.
+func rewriteFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	mtime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func publicKeyPEM(t *testing.T, key any) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) string {
+	return fmt.Sprintf(`{"kty": "RSA", "kid": %q, "use": "sig", "n": %q, "e": %q}`, kid,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	)
+}
+
+func ed25519JWK(kid string, key ed25519.PublicKey) string {
+	return fmt.Sprintf(`{"kty": "OKP", "kid": %q, "crv": "Ed25519", "x": %q}`, kid,
+		base64.RawURLEncoding.EncodeToString(key),
+	)
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("loads PEM public keys", func(t *testing.T) {
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddKeyFile(writeFile(t, "rsa.pem", publicKeyPEM(t, &rsaKey.PublicKey))))
+		assert.NoError(t, ks.AddKeyFile(writeFile(t, "ed.pem", publicKeyPEM(t, edPub))))
+
+		assert.Equal(t, []auth.Key{{Key: &rsaKey.PublicKey}, {Key: edPub}}, ks.Keys("any"))
+	})
+
+	t.Run("loads PKCS1 RSA public keys", func(t *testing.T) {
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddKeyFile(writeFile(t, "rsa.pem", data)))
+		assert.Equal(t, []auth.Key{{Key: &rsaKey.PublicKey}}, ks.Keys(""))
+	})
+
+	t.Run("loads secrets", func(t *testing.T) {
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddSecretFile(writeFile(t, "secret", []byte("secret\n"))))
+		assert.Equal(t, []auth.Key{{Key: []byte("secret")}}, ks.Keys(""))
+	})
+
+	t.Run("loads JWKS signature keys", func(t *testing.T) {
+		data := fmt.Sprintf(`{"keys": [%s, %s, {"kty": "oct", "kid": "hs", "k": "c2VjcmV0"}, {"kty": "EC", "kid": "ec"}, {"kty": "oct", "kid": "enc", "use": "enc", "k": "c2VjcmV0"}]}`,
+			rsaJWK("rs", &rsaKey.PublicKey), ed25519JWK("ed", edPub))
+
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddJWKSFile(writeFile(t, "jwks.json", []byte(data))))
+
+		assert.Equal(t, []auth.Key{{ID: "rs", Key: &rsaKey.PublicKey}}, ks.Keys("rs"))
+		assert.Equal(t, []auth.Key{{ID: "ed", Key: edPub}}, ks.Keys("ed"))
+		assert.Equal(t, []auth.Key{{ID: "hs", Key: []byte("secret")}}, ks.Keys("hs"))
+		assert.Empty(t, ks.Keys("ec"))
+		assert.Empty(t, ks.Keys("enc"))
+		assert.Len(t, ks.Keys(""), 3)
+	})
+
+	for name, tc := range map[string]struct {
+		add  func(*auth.KeySet, string) error
+		data string
+	}{
+		"empty secret":     {(*auth.KeySet).AddSecretFile, ""},
+		"non PEM key":      {(*auth.KeySet).AddKeyFile, "test"},
+		"unsupported PEM":  {(*auth.KeySet).AddKeyFile, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("test")}))},
+		"non JSON JWKS":    {(*auth.KeySet).AddJWKSFile, "test"},
+		"invalid RSA JWK":  {(*auth.KeySet).AddJWKSFile, `{"keys": [{"kty": "RSA", "n": "!", "e": "AQAB"}]}`},
+		"RSA JWK exponent": {(*auth.KeySet).AddJWKSFile, `{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQ"}]}`},
+		"invalid OKP JWK":  {(*auth.KeySet).AddJWKSFile, `{"keys": [{"kty": "OKP", "crv": "Ed25519", "x": "AQAB"}]}`},
+		"invalid oct JWK":  {(*auth.KeySet).AddJWKSFile, `{"keys": [{"kty": "oct", "k": ""}]}`},
+	} {
+		tc := tc
+		t.Run("error on "+name, func(t *testing.T) {
+			assert.Error(t, tc.add(auth.NewKeySet(), writeFile(t, "key", []byte(tc.data))))
+		})
+	}
+
+	t.Run("error on missing file", func(t *testing.T) {
+		assert.Error(t, auth.NewKeySet().AddKeyFile(filepath.Join(t.TempDir(), "missing")))
+	})
+
+	t.Run("reloads changed files", func(t *testing.T) {
+		path := writeFile(t, "jwks.json", []byte(fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("old", edPub))))
+
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddJWKSFile(path))
+		assert.Len(t, ks.Keys("old"), 1)
+
+		rewriteFile(t, path, []byte(fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("new", edPub))))
+		ks.Reload()
+
+		assert.Empty(t, ks.Keys("old"))
+		assert.Len(t, ks.Keys("new"), 1)
+	})
+
+	t.Run("keeps previous keys when reloading fails", func(t *testing.T) {
+		path := writeFile(t, "jwks.json", []byte(fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("old", edPub))))
+
+		ks := auth.NewKeySet()
+		assert.NoError(t, ks.AddJWKSFile(path))
+
+		rewriteFile(t, path, []byte("test"))
+		ks.Reload()
+
+		assert.Len(t, ks.Keys("old"), 1)
+	})
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request does
+	// not carry the credentials it handles.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by an Authenticator when the request
+	// carries credentials it handles but that are not valid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator authenticates the caller of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Middleware authenticates requests with the first authenticator handling
+// their credentials and stores the principal into the request context, and
+// its user ID into the context logger. Unauthenticated requests are rejected.
+func Middleware(authns ...Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, authn := range authns {
+			p, err := authn.Authenticate(ctx.Request)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+
+			switch {
+			case err == nil:
+				logger := log.Ctx(ctx.Request.Context()).With().Str("user_id", p.UserID.String()).Logger()
+				ctx.Request = ctx.Request.WithContext(logger.WithContext(WithPrincipal(ctx.Request.Context(), p)))
+				ctx.Next()
+			case errors.Is(err, ErrInvalidCredentials):
+				log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not authenticate request")
+				unauthorized(ctx)
+			default:
+				log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while authenticating request")
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		unauthorized(ctx)
+	}
+}
+
+// RequireScope rejects requests whose principal is not granted the given
+// scope.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		p, ok := PrincipalFrom(ctx.Request.Context())
+		switch {
+		case !ok:
+			unauthorized(ctx)
+		case !p.HasScope(scope):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		default:
+			ctx.Next()
+		}
+	}
+}
+
+// RequireOrg rejects requests whose principal does not belong to the
+// organization of the request, see tenant.Middleware. Principals whose
+// credentials do not tell their organization, such as client certificates or
+// tokens without organization claim, only act in defaultOrg.
+func RequireOrg(defaultOrg uuid.UUID) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		p, ok := PrincipalFrom(ctx.Request.Context())
+		if !ok {
+			unauthorized(ctx)
+			return
+		}
+
+		bound := p.Org
+		if bound == uuid.Nil {
+			bound = defaultOrg
+		}
+
+		org, _ := tenant.OrgFrom(ctx.Request.Context())
+		if bound == uuid.Nil || bound != org {
+			log.Ctx(ctx.Request.Context()).Warn().Str("principal_org", p.Org.String()).Msg("principal does not belong to the organization")
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func unauthorized(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.AbortWithStatus(http.StatusUnauthorized)
+}
+
+// BearerToken returns the token of the request Authorization header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
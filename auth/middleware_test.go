@@ -0,0 +1,211 @@
+package auth_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+type stubAuthenticator struct {
+	principal auth.Principal
+	err       error
+	called    bool
+}
+
+func (sa *stubAuthenticator) Authenticate(r *http.Request) (auth.Principal, error) {
+	sa.called = true
+	return sa.principal, sa.err
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(authns ...auth.Authenticator) (*httptest.ResponseRecorder, *auth.Principal) {
+		var got *auth.Principal
+
+		router := gin.New()
+		router.GET("/", auth.Middleware(authns...), func(ctx *gin.Context) {
+			if p, ok := auth.PrincipalFrom(ctx.Request.Context()); ok {
+				got = &p
+			}
+			ctx.Status(http.StatusNoContent)
+		})
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		router.ServeHTTP(rr, req)
+
+		return rr, got
+	}
+
+	t.Run("stores the principal of the first authenticator with credentials", func(t *testing.T) {
+		p := auth.Principal{UserID: uuid.New()}
+		skipped := &stubAuthenticator{err: auth.ErrNoCredentials}
+		used := &stubAuthenticator{principal: p}
+		unused := &stubAuthenticator{}
+
+		rr, got := serve(skipped, used, unused)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, &p, got)
+		assert.True(t, skipped.called)
+		assert.False(t, unused.called)
+	})
+
+	t.Run("adds the user ID to the context logger", func(t *testing.T) {
+		p := auth.Principal{UserID: uuid.New()}
+		buf := new(bytes.Buffer)
+
+		router := gin.New()
+		router.GET("/", auth.Middleware(&stubAuthenticator{principal: p}), func(ctx *gin.Context) {
+			log.Ctx(ctx.Request.Context()).Info().Msg("test")
+		})
+
+		logger := zerolog.New(buf)
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		router.ServeHTTP(httptest.NewRecorder(), req.WithContext(logger.WithContext(req.Context())))
+
+		assert.Equal(t, p.UserID.String(), gjson.Get(buf.String(), "user_id").String())
+	})
+
+	t.Run("returns 401 without credentials", func(t *testing.T) {
+		rr, got := serve(&stubAuthenticator{err: auth.ErrNoCredentials})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+		assert.Nil(t, got)
+	})
+
+	t.Run("returns 401 on invalid credentials", func(t *testing.T) {
+		unused := &stubAuthenticator{}
+		rr, got := serve(&stubAuthenticator{err: auth.ErrInvalidCredentials}, unused)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Nil(t, got)
+		assert.False(t, unused.called)
+	})
+
+	t.Run("returns 500 on authenticator error", func(t *testing.T) {
+		rr, got := serve(&stubAuthenticator{err: errors.New("test")})
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Nil(t, got)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(p *auth.Principal) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/", auth.RequireScope(auth.ScopeTodoWrite), func(ctx *gin.Context) {
+			ctx.Status(http.StatusNoContent)
+		})
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		if p != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *p))
+		}
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("lets granted principals through", func(t *testing.T) {
+		rr := serve(&auth.Principal{Scopes: []string{auth.ScopeTodoWrite}})
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns 403 on non granted principals", func(t *testing.T) {
+		rr := serve(&auth.Principal{Scopes: []string{auth.ScopeTodoRead}})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 401 without principal", func(t *testing.T) {
+		rr := serve(nil)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestRequireOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultOrg, acmeOrg := uuid.New(), uuid.New()
+
+	serve := func(defaultOrg, org uuid.UUID, p *auth.Principal) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/", auth.RequireOrg(defaultOrg), func(ctx *gin.Context) {
+			ctx.Status(http.StatusNoContent)
+		})
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		req = req.WithContext(tenant.WithOrg(req.Context(), org))
+		if p != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *p))
+		}
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("lets principals of the organization through", func(t *testing.T) {
+		rr := serve(defaultOrg, acmeOrg, &auth.Principal{Org: acmeOrg})
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("lets principals without organization act in the default one", func(t *testing.T) {
+		rr := serve(defaultOrg, defaultOrg, &auth.Principal{})
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns 403 on principals of other organizations", func(t *testing.T) {
+		rr := serve(defaultOrg, defaultOrg, &auth.Principal{Org: acmeOrg})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 403 on principals without organization out of the default one", func(t *testing.T) {
+		rr := serve(defaultOrg, acmeOrg, &auth.Principal{})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 403 on principals without organization when there is no default one", func(t *testing.T) {
+		rr := serve(uuid.Nil, acmeOrg, &auth.Principal{})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 401 without principal", func(t *testing.T) {
+		rr := serve(defaultOrg, defaultOrg, nil)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestBearerToken(t *testing.T) {
+	for header, expected := range map[string]string{
+		"Bearer token": "token",
+		"bearer token": "token",
+		"Basic token":  "",
+		"Bearer ":      "",
+		"Bearer":       "",
+		"":             "",
+	} {
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		req.Header.Set("Authorization", header)
+
+		token, ok := auth.BearerToken(req)
+		assert.Equal(t, expected, token, header)
+		assert.Equal(t, expected != "", ok, header)
+	}
+}
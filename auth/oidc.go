@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// oidcCacheTTL is how long the discovery document and keys are cached.
+	oidcCacheTTL = time.Hour
+	// oidcFetchTimeout bounds the fetching of a document.
+	oidcFetchTimeout = 10 * time.Second
+	// oidcMaxDocumentSize bounds the size of a fetched document.
+	oidcMaxDocumentSize = 1 << 20
+)
+
+// oidcRefreshInterval is the minimum delay between two refreshes, so that
+// tokens with unknown key IDs do not hammer the provider.
+var oidcRefreshInterval = 10 * time.Second
+
+// OIDCProvider is an OpenID Connect provider whose discovery document and
+// signing keys are fetched and cached. Besides http and https, the issuer URL
+// can use the file scheme to read a local stand-in, whose JWKS URL can then
+// use the file scheme too. Providers served over http or https can not make
+// the server read local files.
+type OIDCProvider struct {
+	issuerURL string
+	client    *http.Client
+
+	mu        sync.Mutex
+	issuer    string
+	keys      []Key
+	fetched   time.Time
+	attempted time.Time
+	// refreshing is closed once the refresh in flight, if any, is done
+	refreshing chan struct{}
+}
+
+// NewOIDCProvider discovers the provider of the given issuer URL and fetches
+// its signing keys.
+func NewOIDCProvider(issuerURL string) (*OIDCProvider, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if isFileURL(issuerURL) {
+		transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	}
+
+	p := &OIDCProvider{
+		issuerURL: strings.TrimSuffix(issuerURL, "/"),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   oidcFetchTimeout,
+		},
+	}
+
+	issuer, keys, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	p.issuer, p.keys = issuer, keys
+	p.fetched, p.attempted = time.Now(), time.Now()
+
+	return p, nil
+}
+
+// Issuer returns the issuer identifier tokens of the provider are issued by.
+func (p *OIDCProvider) Issuer() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.issuer
+}
+
+// Keys implements KeySource. Keys are refreshed when the cache expires or
+// when no key matches the given key ID.
+func (p *OIDCProvider) Keys(kid string) []Key {
+	p.mu.Lock()
+
+	res := matchKeys(p.keys, kid)
+
+	stale := time.Since(p.fetched) >= oidcCacheTTL
+	miss := len(res) == 0
+	if !stale && !miss {
+		p.mu.Unlock()
+		return res
+	}
+
+	// the provider is fetched without holding the lock, once for concurrent
+	// callers which wait for the refresh in flight
+	done := p.refreshing
+	switch {
+	case done != nil:
+		p.mu.Unlock()
+		<-done
+	case time.Since(p.attempted) >= oidcRefreshInterval:
+		done = make(chan struct{})
+		p.refreshing, p.attempted = done, time.Now()
+		p.mu.Unlock()
+		p.refresh(done)
+	default:
+		p.mu.Unlock()
+		return res
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return matchKeys(p.keys, kid)
+}
+
+// refresh fetches the provider, swaps the keys in and closes done.
+func (p *OIDCProvider) refresh(done chan struct{}) {
+	issuer, keys, err := p.discover()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		log.Error().Err(err).Str("issuer", p.issuerURL).Msg("could not refresh OIDC provider keys")
+	} else {
+		p.issuer, p.keys, p.fetched = issuer, keys, time.Now()
+	}
+
+	p.refreshing = nil
+	close(done)
+}
+
+// discover fetches the discovery document then the signing keys.
+func (p *OIDCProvider) discover() (string, []Key, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := p.fetch(p.issuerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return "", nil, fmt.Errorf("could not fetch discovery document: %w", err)
+	}
+
+	switch {
+	case discovery.Issuer == "" || discovery.JWKSURI == "":
+		return "", nil, errors.New("discovery document misses issuer or jwks_uri")
+	// a local stand-in can not be served from the issuer URL
+	case !isFileURL(p.issuerURL) && strings.TrimSuffix(discovery.Issuer, "/") != p.issuerURL:
+		return "", nil, fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, p.issuerURL)
+	case !isFileURL(p.issuerURL) && !strings.HasPrefix(discovery.JWKSURI, "https://") && !strings.HasPrefix(discovery.JWKSURI, "http://"):
+		return "", nil, fmt.Errorf("discovery document jwks_uri %q is not an http or https URL", discovery.JWKSURI)
+	}
+
+	var jwks json.RawMessage
+	if err := p.fetch(discovery.JWKSURI, &jwks); err != nil {
+		return "", nil, fmt.Errorf("could not fetch JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not parse JWKS: %w", err)
+	}
+
+	return discovery.Issuer, keys, nil
+}
+
+// isFileURL tells whether url uses the file scheme.
+func isFileURL(url string) bool {
+	return strings.HasPrefix(strings.ToLower(url), "file:")
+}
+
+func (p *OIDCProvider) fetch(url string, v any) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status fetching %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxDocumentSize)).Decode(v)
+}
@@ -0,0 +1,183 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProvider writes a local OIDC provider stand-in serving the given JWKS
+// and returns its file URL.
+func writeProvider(t *testing.T, issuer, jwks string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".well-known"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jwks.json"), []byte(jwks), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".well-known", "openid-configuration"), []byte(fmt.Sprintf(
+		`{"issuer": %q, "jwks_uri": %q}`, issuer, "file://"+filepath.Join(dir, "jwks.json"))), 0o600))
+
+	return "file://" + dir
+}
+
+func TestOIDCProvider(t *testing.T) {
+	const issuer = "https://idp.example.com"
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("discovers local provider", func(t *testing.T) {
+		SUT, err := auth.NewOIDCProvider(writeProvider(t, issuer, fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("ed", pub))))
+		require.NoError(t, err)
+
+		assert.Equal(t, issuer, SUT.Issuer())
+		assert.Len(t, SUT.Keys("ed"), 1)
+	})
+
+	t.Run("verifies access tokens", func(t *testing.T) {
+		provider, err := auth.NewOIDCProvider(writeProvider(t, issuer, fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("ed", pub))))
+		require.NoError(t, err)
+		SUT := auth.NewJWTAuthenticator(provider, provider.Issuer(), "todo", auth.ClaimMapping{Groups: "roles"})
+
+		token := sign(t, jwt.SigningMethodEdDSA, "ed", priv, jwt.MapClaims{
+			"iss":   issuer,
+			"aud":   "todo",
+			"sub":   "jane",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"email": "jane@example.com",
+			"roles": []string{"dev"},
+		})
+
+		p, err := SUT.Authenticate(bearerRequest(token))
+		require.NoError(t, err)
+		assert.Equal(t, auth.SubjectID(issuer, "jane"), p.UserID)
+		assert.Equal(t, "jane@example.com", p.Email)
+		assert.Equal(t, []string{"dev"}, p.Groups)
+	})
+
+	t.Run("refreshes keys on key ID miss", func(t *testing.T) {
+		defer auth.SetOIDCRefreshInterval(0)()
+
+		url := writeProvider(t, issuer, fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("ed", pub)))
+		SUT, err := auth.NewOIDCProvider(url)
+		require.NoError(t, err)
+		assert.Empty(t, SUT.Keys("rotated"))
+
+		require.NoError(t, os.WriteFile(filepath.Join(url[len("file://"):], "jwks.json"),
+			[]byte(fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("rotated", pub))), 0o600))
+
+		assert.Len(t, SUT.Keys("rotated"), 1)
+	})
+
+	t.Run("throttles refreshes", func(t *testing.T) {
+		defer auth.SetOIDCRefreshInterval(time.Hour)()
+
+		url := writeProvider(t, issuer, `{"keys": []}`)
+		SUT, err := auth.NewOIDCProvider(url)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(url[len("file://"):], "jwks.json"),
+			[]byte(fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("ed", pub))), 0o600))
+
+		assert.Empty(t, SUT.Keys("ed"))
+	})
+
+	t.Run("refreshes without holding the lock", func(t *testing.T) {
+		defer auth.SetOIDCRefreshInterval(0)()
+
+		var fetches atomic.Int32
+		fetching, release := make(chan struct{}), make(chan struct{})
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/.well-known/openid-configuration":
+				fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, srv.URL, srv.URL+"/keys")
+			case "/keys":
+				if fetches.Add(1) == 2 {
+					close(fetching)
+					<-release
+				}
+				fmt.Fprintf(w, `{"keys": [%s]}`, ed25519JWK("ed", pub))
+			}
+		}))
+		defer srv.Close()
+
+		SUT, err := auth.NewOIDCProvider(srv.URL)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Empty(t, SUT.Keys("rotated"))
+			}()
+		}
+
+		<-fetching
+		assert.Equal(t, srv.URL, SUT.Issuer())
+		assert.Len(t, SUT.Keys("ed"), 1)
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("fetches discovery over http", func(t *testing.T) {
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/.well-known/openid-configuration":
+				fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, srv.URL, srv.URL+"/keys")
+			case "/keys":
+				fmt.Fprintf(w, `{"keys": [%s]}`, ed25519JWK("ed", pub))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer srv.Close()
+
+		SUT, err := auth.NewOIDCProvider(srv.URL + "/")
+		require.NoError(t, err)
+		assert.Equal(t, srv.URL, SUT.Issuer())
+		assert.Len(t, SUT.Keys("ed"), 1)
+	})
+
+	t.Run("fails on issuer mismatch", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": "https://idp.example.com/keys"}`, issuer)
+		}))
+		defer srv.Close()
+
+		_, err := auth.NewOIDCProvider(srv.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails on local JWKS of a remote provider", func(t *testing.T) {
+		local := writeProvider(t, issuer, fmt.Sprintf(`{"keys": [%s]}`, ed25519JWK("ed", pub)))
+
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": %q}`, srv.URL, local+"/jwks.json")
+		}))
+		defer srv.Close()
+
+		_, err := auth.NewOIDCProvider(srv.URL)
+		assert.ErrorContains(t, err, "not an http or https URL")
+	})
+
+	t.Run("fails on missing discovery document", func(t *testing.T) {
+		_, err := auth.NewOIDCProvider("file://" + t.TempDir())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,57 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := auth.HashPassword("password")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=65536,t=3,p=4\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`, hash)
+
+	other, err := auth.HashPassword("password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "hashes should be salted")
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := auth.HashPassword("password")
+	assert.NoError(t, err)
+
+	t.Run("matching password", func(t *testing.T) {
+		ok, err := auth.CheckPassword(hash, "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("non matching password", func(t *testing.T) {
+		ok, err := auth.CheckPassword(hash, "Password")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("hash with other parameters", func(t *testing.T) {
+		const hash = "$argon2id$v=19$m=16,t=2,p=1$c29tZXNhbHQ$97FcQ2XrXRGBu161IDNkhQ"
+		ok, err := auth.CheckPassword(hash, "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	for name, hash := range map[string]string{
+		"empty":         "",
+		"other algo":    "$argon2i$v=19$m=16,t=2,p=1$c29tZXNhbHQ$97FcQ2XrXRGBu161IDNkhQ",
+		"other version": "$argon2id$v=16$m=16,t=2,p=1$c29tZXNhbHQ$97FcQ2XrXRGBu161IDNkhQ",
+		"bad params":    "$argon2id$v=19$m=x,t=2,p=1$c29tZXNhbHQ$97FcQ2XrXRGBu161IDNkhQ",
+		"bad salt":      "$argon2id$v=19$m=16,t=2,p=1$!!$1ARp/hXnCfhE6S01zMsmOw",
+		"bad key":       "$argon2id$v=19$m=16,t=2,p=1$c29tZXNhbHQ$!!",
+	} {
+		hash := hash
+		t.Run("malformed hash: "+name, func(t *testing.T) {
+			_, err := auth.CheckPassword(hash, "password")
+			assert.ErrorIs(t, err, auth.ErrMalformedHash)
+		})
+	}
+}
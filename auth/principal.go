@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Scopes a principal can be restricted to.
+const (
+	ScopeTodoRead  = "todo:read"
+	ScopeTodoWrite = "todo:write"
+	ScopeAdmin     = "admin"
+)
+
+type principalKey struct{}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uuid.UUID
+	Email  string
+	Groups []string
+	// Scopes restricts what the principal can do. Nil means no restriction.
+	Scopes []string
+	// Org is the organization the principal belongs to, uuid.Nil when its
+	// credentials do not tell, see RequireOrg.
+	Org uuid.UUID
+	// APIKeyID is the API key the principal authenticated with, uuid.Nil
+	// with other credentials.
+	APIKeyID uuid.UUID
+}
+
+// HasScope reports whether the principal is granted the given scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithPrincipal returns a copy of ctx carrying the given principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal carried by ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,45 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal(t *testing.T) {
+	t.Run("is carried by the context", func(t *testing.T) {
+		p := auth.Principal{UserID: uuid.New()}
+
+		_, ok := auth.PrincipalFrom(context.Background())
+		assert.False(t, ok)
+
+		got, ok := auth.PrincipalFrom(auth.WithPrincipal(context.Background(), p))
+		assert.True(t, ok)
+		assert.Equal(t, p, got)
+	})
+
+	t.Run("without scopes is not restricted", func(t *testing.T) {
+		p := auth.Principal{}
+		assert.True(t, p.HasScope(auth.ScopeTodoRead))
+		assert.True(t, p.HasScope(auth.ScopeAdmin))
+	})
+
+	t.Run("with scopes is restricted to them", func(t *testing.T) {
+		p := auth.Principal{Scopes: []string{auth.ScopeTodoRead}}
+		assert.True(t, p.HasScope(auth.ScopeTodoRead))
+		assert.False(t, p.HasScope(auth.ScopeTodoWrite))
+		assert.False(t, p.HasScope(auth.ScopeAdmin))
+
+		p = auth.Principal{Scopes: []string{}}
+		assert.False(t, p.HasScope(auth.ScopeTodoRead))
+	})
+
+	t.Run("with admin scope is granted every scope", func(t *testing.T) {
+		p := auth.Principal{Scopes: []string{auth.ScopeAdmin}}
+		assert.True(t, p.HasScope(auth.ScopeTodoRead))
+		assert.True(t, p.HasScope(auth.ScopeTodoWrite))
+	})
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/tenant"
+)
+
+type SessionRepo interface {
+	GetSessionUser(ctx context.Context, tokenHash []byte) (model.User, error)
+}
+
+// SessionAuthenticator authenticates requests bearing a session token issued
+// on login.
+type SessionAuthenticator struct {
+	repo SessionRepo
+}
+
+// NewSessionAuthenticator instantiates SessionAuthenticator.
+func NewSessionAuthenticator(repo SessionRepo) SessionAuthenticator {
+	return SessionAuthenticator{
+		repo: repo,
+	}
+}
+
+// Authenticate implements Authenticator.
+func (a SessionAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return Principal{}, ErrNoCredentials
+	}
+
+	user, err := a.repo.GetSessionUser(r.Context(), HashToken(token))
+	switch {
+	case errors.Is(err, repository.ErrSessionNotFound):
+		return Principal{}, fmt.Errorf("%w: unknown or expired session", ErrInvalidCredentials)
+	case err != nil:
+		return Principal{}, fmt.Errorf("could not get session: %w", err)
+	}
+
+	// sessions are looked up in the organization of the request only
+	org, _ := tenant.OrgFrom(r.Context())
+
+	return Principal{
+		UserID: user.ID,
+		Email:  user.Email,
+		Org:    org,
+	}, nil
+}
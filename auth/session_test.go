@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubSessionRepo struct {
+	user      model.User
+	err       error
+	tokenHash []byte
+}
+
+func (sr *stubSessionRepo) GetSessionUser(ctx context.Context, tokenHash []byte) (model.User, error) {
+	sr.tokenHash = tokenHash
+	return sr.user, sr.err
+}
+
+func TestSessionAuthenticator(t *testing.T) {
+	request := func(header string) *http.Request {
+		req, _ := http.NewRequest("GET", "/", http.NoBody)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		return req
+	}
+
+	t.Run("authenticates a valid session", func(t *testing.T) {
+		user := model.User{ID: uuid.New(), Email: "john@example.com"}
+		repo := &stubSessionRepo{user: user}
+
+		org := uuid.New()
+		req := request("Bearer token")
+		req = req.WithContext(tenant.WithOrg(req.Context(), org))
+
+		p, err := auth.NewSessionAuthenticator(repo).Authenticate(req)
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{UserID: user.ID, Email: user.Email, Org: org}, p)
+		assert.Equal(t, auth.HashToken("token"), repo.tokenHash)
+	})
+
+	t.Run("no credentials without bearer token", func(t *testing.T) {
+		_, err := auth.NewSessionAuthenticator(&stubSessionRepo{}).Authenticate(request(""))
+		assert.ErrorIs(t, err, auth.ErrNoCredentials)
+	})
+
+	t.Run("invalid credentials on unknown session", func(t *testing.T) {
+		repo := &stubSessionRepo{err: repository.ErrSessionNotFound}
+
+		_, err := auth.NewSessionAuthenticator(repo).Authenticate(request("Bearer token"))
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("error on repo error", func(t *testing.T) {
+		repo := &stubSessionRepo{err: errors.New("test")}
+
+		_, err := auth.NewSessionAuthenticator(repo).Authenticate(request("Bearer token"))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+}
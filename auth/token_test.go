@@ -0,0 +1,24 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewToken(t *testing.T) {
+	token, err := auth.NewToken()
+	assert.NoError(t, err)
+	assert.Len(t, token, 43)
+
+	other, err := auth.NewToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, auth.HashToken("token"), auth.HashToken("token"))
+	assert.NotEqual(t, auth.HashToken("token"), auth.HashToken("other"))
+	assert.Len(t, auth.HashToken("token"), 32)
+}
@@ -0,0 +1,68 @@
+// Package authz decides what users can do on the todo lists they own or that
+// are shared with them.
+package authz
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the user can not see the resource at all,
+	// so that its existence is not leaked.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the user can see the resource but not
+	// perform the action on it.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Role is the role of a user on a todo list.
+type Role string
+
+const (
+	// RoleNone is the role of users the list is not shared with.
+	RoleNone   Role = ""
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+// Action is an action performed on a todo list.
+type Action int
+
+const (
+	// ActionRead reads the todos of the list.
+	ActionRead Action = iota
+	// ActionWrite adds or updates todos of the list.
+	ActionWrite
+	// ActionShare manages who the list is shared with.
+	ActionShare
+)
+
+// grants lists the actions granted to each role.
+var grants = map[Role][]Action{
+	RoleViewer: {ActionRead},
+	RoleEditor: {ActionRead, ActionWrite},
+	RoleOwner:  {ActionRead, ActionWrite, ActionShare},
+}
+
+// Can reports whether the role grants the action.
+func (r Role) Can(action Action) bool {
+	for _, a := range grants[r] {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize returns nil when the role grants the action, ErrNotFound when the
+// role can not even read the list and ErrForbidden otherwise.
+func Authorize(role Role, action Action) error {
+	switch {
+	case role.Can(action):
+		return nil
+	case role.Can(ActionRead):
+		return ErrForbidden
+	default:
+		return ErrNotFound
+	}
+}
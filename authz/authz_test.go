@@ -0,0 +1,42 @@
+package authz_test
+
+import (
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/authz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := map[authz.Role]map[authz.Action]error{
+		authz.RoleOwner: {
+			authz.ActionRead:  nil,
+			authz.ActionWrite: nil,
+			authz.ActionShare: nil,
+		},
+		authz.RoleEditor: {
+			authz.ActionRead:  nil,
+			authz.ActionWrite: nil,
+			authz.ActionShare: authz.ErrForbidden,
+		},
+		authz.RoleViewer: {
+			authz.ActionRead:  nil,
+			authz.ActionWrite: authz.ErrForbidden,
+			authz.ActionShare: authz.ErrForbidden,
+		},
+		authz.RoleNone: {
+			authz.ActionRead:  authz.ErrNotFound,
+			authz.ActionWrite: authz.ErrNotFound,
+			authz.ActionShare: authz.ErrNotFound,
+		},
+		authz.Role("unknown"): {
+			authz.ActionRead: authz.ErrNotFound,
+		},
+	}
+
+	for role, actions := range tests {
+		for action, expected := range actions {
+			assert.Equal(t, expected, authz.Authorize(role, action), "role %q action %d", role, action)
+		}
+	}
+}
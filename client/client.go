@@ -0,0 +1,166 @@
+// Package client calls the todo API over HTTP.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/problem"
+)
+
+// OrgHeader is the header holding the organization of requests, the default
+// one of the server.
+const OrgHeader = "X-Org-ID"
+
+// requestTimeout bounds every call, so that an unresponsive server does not
+// hang the caller.
+const requestTimeout = 30 * time.Second
+
+// maxBodySize bounds the responses read, error ones included.
+const maxBodySize = 10 << 20
+
+// Error is an error response of the API.
+type Error struct {
+	Status int
+	// Problem is empty when the response is not a problem details one.
+	Problem problem.Details
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	if e.Problem.RequestID != "" {
+		msg += " (request " + e.Problem.RequestID + ")"
+	}
+	return msg
+}
+
+// Client calls the API at baseURL, authenticated with a bearer token, an API
+// key or a session token.
+type Client struct {
+	baseURL string
+	token   string
+	org     string
+	client  *http.Client
+}
+
+// New returns a client of the API at baseURL. The organization is the default
+// one of the server when empty.
+func New(baseURL, token, org string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		org:     org,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Todos returns the todos of the list of owner, the user's own list when
+// uuid.Nil, archived ones included or not.
+func (c *Client) Todos(ctx context.Context, owner uuid.UUID, includeArchived bool) ([]model.Todo, error) {
+	query := url.Values{}
+	if owner != uuid.Nil {
+		query.Set("owner_id", owner.String())
+	}
+	if includeArchived {
+		query.Set("include_archived", "true")
+	}
+
+	var res []model.Todo
+	if err := c.do(ctx, http.MethodGet, "/todo", query, nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Todo returns the todo of the given ID.
+func (c *Client) Todo(ctx context.Context, id uuid.UUID) (model.Todo, error) {
+	var res model.Todo
+	err := c.do(ctx, http.MethodGet, "/todo", url.Values{"id": {id.String()}}, nil, &res)
+	return res, err
+}
+
+// AddTodo adds a todo to the list of owner, the user's own list when
+// uuid.Nil.
+func (c *Client) AddTodo(ctx context.Context, owner uuid.UUID, message string) (model.Todo, error) {
+	query := url.Values{}
+	if owner != uuid.Nil {
+		query.Set("owner_id", owner.String())
+	}
+
+	var res model.Todo
+	err := c.do(ctx, http.MethodPost, "/todo", query, model.Todo{Message: message}, &res)
+	return res, err
+}
+
+// do sends a request with the JSON encoded body, when not nil, and decodes
+// the JSON response into res. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, res any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.org != "" {
+		req.Header.Set(OrgHeader, c.org)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("could not read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		e := &Error{Status: resp.StatusCode}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), problem.ContentType) {
+			// the status is enough when the body is not a valid one
+			_ = json.Unmarshal(data, &e.Problem)
+		}
+		return e
+	}
+
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/client"
+	"github.com/iciantoine/todo-go-api/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	id := uuid.MustParse("b33b1c9e-0f3d-4a43-9b6a-1ab4bd4e2e0a")
+	owner := uuid.MustParse("2c7f3ac3-5b5e-4e0b-8d84-8d7bfc4dc2c9")
+
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		switch {
+		case r.URL.Query().Has("id"):
+			_, _ = w.Write([]byte(`{"id": "` + id.String() + `", "message": "buy milk"}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": "` + id.String() + `", "message": "buy milk"}`))
+		default:
+			_, _ = w.Write([]byte(`[{"id": "` + id.String() + `", "message": "buy milk", "is_done": true}]`))
+		}
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL+"/", "s3cret", "00000000-0000-0000-0000-000000000001")
+
+	t.Run("lists todos", func(t *testing.T) {
+		todos, err := c.Todos(context.Background(), owner, true)
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		assert.True(t, todos[0].IsDone)
+
+		assert.Equal(t, "/todo", got.URL.Path)
+		assert.Equal(t, owner.String(), got.URL.Query().Get("owner_id"))
+		assert.Equal(t, "true", got.URL.Query().Get("include_archived"))
+		assert.Equal(t, "Bearer s3cret", got.Header.Get("Authorization"))
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", got.Header.Get(client.OrgHeader))
+	})
+
+	t.Run("gets a todo", func(t *testing.T) {
+		todo, err := c.Todo(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, id, todo.ID)
+		assert.Equal(t, id.String(), got.URL.Query().Get("id"))
+	})
+
+	t.Run("adds a todo", func(t *testing.T) {
+		todo, err := c.AddTodo(context.Background(), uuid.Nil, "buy milk")
+		require.NoError(t, err)
+		assert.Equal(t, "buy milk", todo.Message)
+		assert.Equal(t, http.MethodPost, got.Method)
+		assert.False(t, got.URL.Query().Has("owner_id"))
+	})
+}
+
+func TestClientError(t *testing.T) {
+	t.Run("returns problem details", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", problem.ContentType)
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type": "about:blank", "title": "Not Found", "status": 404, "request_id": "abc"}`))
+		}))
+		defer srv.Close()
+
+		_, err := client.New(srv.URL, "", "").Todo(context.Background(), uuid.New())
+
+		var e *client.Error
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusNotFound, e.Status)
+		assert.Equal(t, "abc", e.Problem.RequestID)
+		assert.Equal(t, "404 Not Found (request abc)", e.Error())
+	})
+
+	t.Run("returns the status of other bodies", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Not Implemented", http.StatusNotImplemented)
+		}))
+		defer srv.Close()
+
+		_, err := client.New(srv.URL, "", "").Todos(context.Background(), uuid.Nil, false)
+
+		var e *client.Error
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusNotImplemented, e.Status)
+	})
+
+	t.Run("error on invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<html>`))
+		}))
+		defer srv.Close()
+
+		_, err := client.New(srv.URL, "", "").Todos(context.Background(), uuid.Nil, false)
+		assert.Error(t, err)
+	})
+}
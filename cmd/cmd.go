@@ -7,26 +7,65 @@ import (
 	"syscall"
 )
 
-// Run runs the given function with a context that is closed as soon as an OS
-// signal is caught.
-func Run(f func(context.Context) error) error {
-	ctx, cancel := signal.NotifyContext(
-		context.Background(),
-		syscall.SIGHUP,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-	)
+// Option configures Run.
+type Option func(*runConfig)
+
+type runConfig struct {
+	hangups bool
+}
+
+// WithHangups leaves hangup signals (SIGHUP) to Hangups, so that they ask to
+// reload instead of closing the context.
+func WithHangups() Option {
+	return func(cfg *runConfig) {
+		cfg.hangups = true
+	}
+}
+
+// Run runs the given function with a context that is closed as soon as a
+// hangup, interrupt, termination or quit signal is caught.
+func Run(f func(context.Context) error, opts ...Option) error {
+	cfg := runConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+	if cfg.hangups {
+		// ignored until Hangups catches them
+		signal.Ignore(syscall.SIGHUP)
+	} else {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), signals...)
 	defer cancel()
 
 	return f(ctx)
 }
 
-// Env returns the value of the given environment variable or uses the provided
-// fallback value instead.
-func Env(name, fallback string) string {
-	if val, ok := os.LookupEnv(name); ok {
-		return val
-	}
-	return fallback
+// Hangups returns a channel receiving a value for every hangup signal (SIGHUP)
+// caught until ctx is closed, asking to reload, see WithHangups.
+func Hangups(ctx context.Context) <-chan struct{} {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	res := make(chan struct{}, 1)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sig:
+				// reloads asked while reloading are merged
+				select {
+				case res <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return res
 }
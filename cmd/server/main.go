@@ -2,34 +2,101 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"os"
 
 	"github.com/iciantoine/todo-go-api/cmd"
+	"github.com/iciantoine/todo-go-api/config"
+	"github.com/iciantoine/todo-go-api/database"
 	"github.com/iciantoine/todo-go-api/server"
+	"github.com/rs/zerolog/log"
 )
 
 func main() {
-	if err := cmd.Run(run); err != nil {
+	err := cmd.Run(run, cmd.WithHangups())
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
 		os.Exit(1)
 	}
 }
 
+// run serves requests, prints the effective configuration when called as
+// "server config print", or migrates the database schema when called as
+// "server migrate up|down|to N|status".
 func run(ctx context.Context) error {
-	return server.Listen(ctx,
-		server.WithApplicationAddress(
-			cmd.Env("APPLICATION_ADDR", "127.0.0.1"),
-			cmd.Env("PORT", "8080"),
-		),
-		server.WithDatabase(
-			cmd.Env("DB_USERNAME", "todo"),
-			cmd.Env("DB_PASSWORD", "todo"),
-			cmd.Env("DB_ADDR", "localhost"),
-			cmd.Env("DB_PORT", "5432"),
-			cmd.Env("DB_NAME", "todo"),
-			cmd.Env("DB_SSL", "disable"),
-		),
-		server.WithLogLevel(
-			cmd.Env("LOGLEVEL", "debug"),
-		),
-	)
+	args := os.Args[1:]
+
+	printConfig := len(args) >= 2 && args[0] == "config" && args[1] == "print"
+	if printConfig {
+		args = args[2:]
+	}
+
+	var migrate func(context.Context, *database.Migrator) error
+	if len(args) >= 1 && args[0] == "migrate" {
+		var err error
+		migrate, args, err = migration(args[1:], os.Stdout)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid migrate command")
+			return err
+		}
+	}
+
+	cfg, err := config.Load(settings, args, os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("could not load configuration")
+		return err
+	}
+
+	opts := options(cfg)
+	if err := server.Validate(opts...); err != nil {
+		log.Error().Err(err).Msg("invalid configuration")
+		return err
+	}
+
+	if printConfig {
+		return cfg.Print(os.Stdout)
+	}
+	if migrate != nil {
+		return server.Migrate(ctx, migrate, opts...)
+	}
+
+	return server.Listen(ctx, append(opts, server.WithReload(cmd.Hangups(ctx), reload(cfg, args)))...)
+}
+
+// reload returns a function loading the configuration again with the same
+// arguments, logging the settings changed since the last load. The TLS
+// certificate can only be rotated when HTTPS was enabled at startup: enabling
+// or disabling it requires a restart.
+func reload(cfg *config.Config, args []string) func() ([]server.Option, error) {
+	tls := cfg.Get("TLS_CERT_FILE") != ""
+
+	return func() ([]server.Option, error) {
+		next, err := config.Load(settings, args, os.LookupEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := options(next)
+		if err := server.Validate(opts...); err != nil {
+			return nil, err
+		}
+
+		for _, c := range config.Diff(cfg, next) {
+			if c.Name == "TLS_CERT_FILE" || c.Name == "TLS_KEY_FILE" {
+				c.Reloadable = tls && next.Get("TLS_CERT_FILE") != ""
+			}
+
+			if c.Reloadable {
+				log.Info().Str("setting", c.Name).Str("old", c.Old).Str("new", c.New).Msg("setting changed")
+			} else {
+				log.Warn().Str("setting", c.Name).Str("old", c.Old).Str("new", c.New).Msg("changed setting requires a restart")
+			}
+		}
+		cfg = next
+
+		return opts, nil
+	}
 }
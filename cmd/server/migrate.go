@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/iciantoine/todo-go-api/database"
+	"github.com/rs/zerolog/log"
+)
+
+// migration returns the function running the "server migrate" subcommand of
+// args, "up", "down", "to N" or "status", and the arguments left.
+func migration(args []string, w io.Writer) (func(context.Context, *database.Migrator) error, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, errors.New("missing migrate command: up, down, to N or status")
+	}
+
+	switch args[0] {
+	case "up":
+		return func(ctx context.Context, m *database.Migrator) error {
+			return migrateTo(ctx, m, len(m.Migrations()))
+		}, args[1:], nil
+	case "down":
+		return func(ctx context.Context, m *database.Migrator) error {
+			version, err := m.Version(ctx)
+			if err != nil {
+				return err
+			}
+			if version == 0 {
+				return errors.New("no migration to revert")
+			}
+			return migrateTo(ctx, m, version-1)
+		}, args[1:], nil
+	case "to":
+		if len(args) < 2 {
+			return nil, nil, errors.New("missing version to migrate to")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid version to migrate to: %s", args[1])
+		}
+		return func(ctx context.Context, m *database.Migrator) error {
+			return migrateTo(ctx, m, version)
+		}, args[2:], nil
+	case "status":
+		return func(ctx context.Context, m *database.Migrator) error {
+			return status(ctx, m, w)
+		}, args[1:], nil
+	default:
+		return nil, nil, fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+}
+
+// migrateTo migrates the schema to version, logging the versions.
+func migrateTo(ctx context.Context, m *database.Migrator, version int) error {
+	from, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := m.MigrateTo(ctx, version); err != nil {
+		return err
+	}
+
+	log.Info().Int("from", from).Int("to", version).Msg("migrated database schema")
+	return nil
+}
+
+// status prints the version of the schema and whether each migration is
+// applied.
+func status(ctx context.Context, m *database.Migrator, w io.Writer) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrations := m.Migrations()
+	if _, err := fmt.Fprintf(w, "version %d of %d\n", version, len(migrations)); err != nil {
+		return err
+	}
+
+	for _, mig := range migrations {
+		state := "pending"
+		if mig.Version <= version {
+			state = "applied"
+		}
+		if _, err := fmt.Fprintf(w, "%03d_%s %s\n", mig.Version, mig.Name, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
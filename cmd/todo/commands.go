@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/client"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+// command is a subcommand of todo.
+type command struct {
+	Name string
+	// Args are the names of the arguments, the last one taking the
+	// arguments left when ending with "...".
+	Args  []string
+	Usage string
+	// Values are the completions of the arguments.
+	Values []string
+	// Flags defines the flags of the command on fs, and returns the function
+	// running the command with its arguments once they are parsed.
+	Flags func(fs *flag.FlagSet) func(ctx context.Context, c *client.Client, args []string) error
+}
+
+// usage returns the command line of the command.
+func (c command) usage() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// checkArgs checks the number of arguments of the command.
+func (c command) checkArgs(args []string) error {
+	variadic := len(c.Args) > 0 && strings.HasSuffix(c.Args[len(c.Args)-1], "...")
+	if len(args) == len(c.Args) || variadic && len(args) > len(c.Args) {
+		return nil
+	}
+	return usageError{fmt.Errorf("usage: todo %s", c.usage())}
+}
+
+// commands returns the commands, by name.
+func commands() map[string]command {
+	res := map[string]command{}
+	for _, c := range []command{
+		{Name: "add", Args: []string{"<message>..."}, Usage: "add a todo", Flags: addCommand},
+		{Name: "ls", Usage: "list todos", Flags: lsCommand},
+		{Name: "show", Args: []string{"<id>"}, Usage: "show a todo", Flags: showCommand},
+		{Name: "watch", Usage: "print changes of todos until interrupted", Flags: watchCommand},
+		{Name: "completion", Args: []string{"<shell>"}, Usage: "print the completion script of bash, zsh or fish", Values: shells(), Flags: completionCommand},
+	} {
+		res[c.Name] = c
+	}
+	return res
+}
+
+// sortedCommands returns the commands, by name.
+func sortedCommands() []command {
+	var res []command
+	for _, c := range commands() {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// ownerFlag defines the --owner flag, the owner of the todo list to act on.
+func ownerFlag(fs *flag.FlagSet) *string {
+	return fs.String("owner", "", "ID of the owner of the list, the user's own list when empty")
+}
+
+// parseOwner parses the value of the --owner flag.
+func parseOwner(owner string) (uuid.UUID, error) {
+	if owner == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(owner)
+	if err != nil {
+		return uuid.Nil, usageError{fmt.Errorf("invalid owner: %s", owner)}
+	}
+	return id, nil
+}
+
+// outputFlag defines the --output flag, the format todos are printed in.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", formatTable, "output format: table, json or csv")
+}
+
+func addCommand(fs *flag.FlagSet) func(context.Context, *client.Client, []string) error {
+	owner := ownerFlag(fs)
+	output := outputFlag(fs)
+
+	return func(ctx context.Context, c *client.Client, args []string) error {
+		id, err := parseOwner(*owner)
+		if err != nil {
+			return err
+		}
+		if err := checkFormat(*output); err != nil {
+			return err
+		}
+
+		todo, err := c.AddTodo(ctx, id, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		return printTodos(os.Stdout, *output, []model.Todo{todo})
+	}
+}
+
+func lsCommand(fs *flag.FlagSet) func(context.Context, *client.Client, []string) error {
+	owner := ownerFlag(fs)
+	output := outputFlag(fs)
+	archived := fs.Bool("archived", false, "include archived todos")
+
+	return func(ctx context.Context, c *client.Client, args []string) error {
+		id, err := parseOwner(*owner)
+		if err != nil {
+			return err
+		}
+		if err := checkFormat(*output); err != nil {
+			return err
+		}
+
+		todos, err := c.Todos(ctx, id, *archived)
+		if err != nil {
+			return err
+		}
+
+		return printTodos(os.Stdout, *output, todos)
+	}
+}
+
+func showCommand(fs *flag.FlagSet) func(context.Context, *client.Client, []string) error {
+	output := outputFlag(fs)
+
+	return func(ctx context.Context, c *client.Client, args []string) error {
+		id, err := uuid.Parse(args[0])
+		if err != nil {
+			return usageError{fmt.Errorf("invalid todo ID: %s", args[0])}
+		}
+		if err := checkFormat(*output); err != nil {
+			return err
+		}
+
+		todo, err := c.Todo(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return printTodos(os.Stdout, *output, []model.Todo{todo})
+	}
+}
+
+func watchCommand(fs *flag.FlagSet) func(context.Context, *client.Client, []string) error {
+	owner := ownerFlag(fs)
+	interval := fs.Duration("interval", 5*time.Second, "how often todos are polled")
+
+	return func(ctx context.Context, c *client.Client, args []string) error {
+		id, err := parseOwner(*owner)
+		if err != nil {
+			return err
+		}
+		if *interval <= 0 {
+			return usageError{fmt.Errorf("invalid interval: %s", *interval)}
+		}
+
+		return watch(ctx, c, id, *interval)
+	}
+}
+
+// watch prints the todos, then their changes every interval until ctx is
+// closed. Errors that may be transient, such as an unreachable or
+// unavailable server, are printed and polling goes on.
+func watch(ctx context.Context, c *client.Client, owner uuid.UUID, interval time.Duration) error {
+	var prev []model.Todo
+	first := true
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		todos, err := c.Todos(ctx, owner, true)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && !transient(err):
+			return err
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "todo:", err)
+		case first:
+			if err := printTodos(os.Stdout, formatTable, todos); err != nil {
+				return err
+			}
+			prev, first = todos, false
+		default:
+			printChanges(os.Stdout, prev, todos)
+			prev = todos
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// transient tells whether a request may succeed when sent again.
+func transient(err error) bool {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		// network errors
+		return true
+	}
+	return apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= http.StatusInternalServerError
+}
+
+func completionCommand(fs *flag.FlagSet) func(context.Context, *client.Client, []string) error {
+	return func(ctx context.Context, c *client.Client, args []string) error {
+		return printCompletion(os.Stdout, args[0])
+	}
+}
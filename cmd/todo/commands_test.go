@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckArgs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args  []string
+		given []string
+		ok    bool
+	}{
+		"no arguments":               {args: nil, given: nil, ok: true},
+		"unexpected argument":        {args: nil, given: []string{"a"}, ok: false},
+		"expected argument":          {args: []string{"<id>"}, given: []string{"a"}, ok: true},
+		"missing argument":           {args: []string{"<id>"}, given: nil, ok: false},
+		"extra argument":             {args: []string{"<id>"}, given: []string{"a", "b"}, ok: false},
+		"variadic argument":          {args: []string{"<message>..."}, given: []string{"a"}, ok: true},
+		"several variadic arguments": {args: []string{"<message>..."}, given: []string{"a", "b"}, ok: true},
+		"missing variadic argument":  {args: []string{"<message>..."}, given: nil, ok: false},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := command{Name: "test", Args: tc.args}.checkArgs(tc.given)
+			if tc.ok {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorAs(t, err, &usageError{})
+		})
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	for name, args := range map[string][]string{
+		"add":  {"test"},
+		"ls":   nil,
+		"show": {"038863e4-2fbe-4bc3-9e38-1e62e93659f5"},
+	} {
+		name, args := name, args
+		t.Run(name+" checks the output format before calling the API", func(t *testing.T) {
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			run := commands()[name].Flags(fs)
+			require.NoError(t, fs.Parse([]string{"--output", "yaml"}))
+
+			// a nil client panics when called
+			err := run(context.Background(), nil, args)
+			assert.ErrorAs(t, err, &usageError{})
+			assert.ErrorContains(t, err, "unknown output format: yaml")
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/iciantoine/todo-go-api/config"
+)
+
+// completions are the completion scripts, by shell. They are given the
+// commands, their flags and the global flags.
+var completions = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(bashCompletion)),
+	// zsh runs the bash script through its bash compatibility
+	"zsh":  template.Must(template.New("zsh").Parse("autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion)),
+	"fish": template.Must(template.New("fish").Parse(fishCompletion)),
+}
+
+const bashCompletion = `_todo() {
+    local cur=${COMP_WORDS[COMP_CWORD]} cmd= i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case ${COMP_WORDS[i]} in
+        {{.GlobalPattern}}) ((i++)) ;;
+        -*) ;;
+        *) cmd=${COMP_WORDS[i]}; break ;;
+        esac
+    done
+
+    case $cmd in
+    "")
+        if [[ $cur == -* ]]; then
+            COMPREPLY=($(compgen -W "{{.Global}}" -- "$cur"))
+        else
+            COMPREPLY=($(compgen -W "{{.Names}}" -- "$cur"))
+        fi
+        ;;
+{{- range .Commands}}
+    {{.Name}}) COMPREPLY=($(compgen -W "{{.Flags}}" -- "$cur")) ;;
+{{- end}}
+    esac
+}
+complete -o default -F _todo todo
+`
+
+const fishCompletion = `complete -c todo -f
+complete -c todo -n __fish_use_subcommand -a "{{.Names}}"
+{{- range .Commands}}
+{{- $name := .Name}}
+{{- if .Values}}
+complete -c todo -n "__fish_seen_subcommand_from {{$name}}" -a "{{.Values}}"
+{{- end}}
+{{- range .FlagNames}}
+complete -c todo -n "__fish_seen_subcommand_from {{$name}}" -l {{.}}
+{{- end}}
+{{- end}}
+{{- range .GlobalNames}}
+complete -c todo -n __fish_use_subcommand -l {{.}}
+{{- end}}
+`
+
+// shells returns the shells completion scripts are printed for.
+func shells() []string {
+	return []string{"bash", "zsh", "fish"}
+}
+
+// printCompletion prints the completion script of shell.
+func printCompletion(w io.Writer, shell string) error {
+	tmpl, ok := completions[shell]
+	if !ok {
+		return usageError{fmt.Errorf("unknown shell: %s", shell)}
+	}
+
+	type completionCommand struct {
+		Name      string
+		FlagNames []string
+		// Flags and Values are space separated
+		Flags  string
+		Values string
+	}
+	data := struct {
+		Names       string
+		Commands    []completionCommand
+		GlobalNames []string
+		Global      string
+		// GlobalPattern matches the global flags, taking a value
+		GlobalPattern string
+	}{}
+
+	var names []string
+	for _, c := range sortedCommands() {
+		names = append(names, c.Name)
+
+		fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+		c.Flags(fs)
+		cc := completionCommand{Name: c.Name, Values: strings.Join(c.Values, " ")}
+		fs.VisitAll(func(f *flag.Flag) {
+			cc.FlagNames = append(cc.FlagNames, f.Name)
+		})
+		cc.Flags = strings.TrimSpace(dashes(cc.FlagNames) + " " + cc.Values)
+		data.Commands = append(data.Commands, cc)
+	}
+	data.Names = strings.Join(names, " ")
+
+	data.GlobalNames = []string{flagName(config.FileSetting)}
+	for _, s := range settings {
+		data.GlobalNames = append(data.GlobalNames, flagName(s.Name))
+		if s.Secret {
+			data.GlobalNames = append(data.GlobalNames, flagName(s.Name+"_FILE"))
+		}
+	}
+	data.Global = dashes(data.GlobalNames)
+	data.GlobalPattern = strings.ReplaceAll(data.Global, " ", "|")
+
+	return tmpl.Execute(w, data)
+}
+
+// flagName returns the flag of a setting, see config.Setting.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// dashes returns the flags of names, space separated.
+func dashes(names []string) string {
+	res := make([]string, len(names))
+	for i, name := range names {
+		res[i] = "--" + name
+	}
+	return strings.Join(res, " ")
+}
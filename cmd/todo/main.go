@@ -0,0 +1,152 @@
+// Command todo is a terminal client of the todo API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/iciantoine/todo-go-api/client"
+	"github.com/iciantoine/todo-go-api/cmd"
+	"github.com/iciantoine/todo-go-api/config"
+)
+
+// envPrefix prefixes the environment variables of the settings, such as
+// TODO_URL for URL.
+const envPrefix = "TODO_"
+
+// Exit codes, by cause of the error.
+const (
+	exitError        = 1 // unexpected errors, such as an unreachable server
+	exitUsage        = 2
+	exitNotFound     = 3
+	exitUnauthorized = 4 // 401 and 403
+	exitInvalid      = 5 // 400
+	exitRateLimited  = 6 // 429
+	exitUnavailable  = 7 // 5xx
+)
+
+var settings = []config.Setting{
+	{Name: "URL", Default: "http://localhost:8080", Usage: "base URL of the API"},
+	{Name: "TOKEN", Usage: "API key or session token", Secret: true},
+	{Name: "ORG_ID", Usage: "organization, the default one of the server when empty"},
+}
+
+// usageError is an error of the command line.
+type usageError struct {
+	error
+}
+
+func main() {
+	err := cmd.Run(run)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "todo:", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// run runs the command of the command line, "todo [flags] command [flags]
+// [args]".
+func run(ctx context.Context) error {
+	cfg, args, err := config.LoadCommand(settings, os.Args[1:], lookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage()
+		return err
+	}
+	if err != nil {
+		return usageError{err}
+	}
+
+	if len(args) == 0 {
+		printUsage()
+		return usageError{errors.New("missing command")}
+	}
+
+	command, ok := commands()[args[0]]
+	if !ok {
+		return usageError{fmt.Errorf("unknown command: %s", args[0])}
+	}
+
+	fs := flag.NewFlagSet("todo "+command.Name, flag.ContinueOnError)
+	runCommand := command.Flags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if err := command.checkArgs(fs.Args()); err != nil {
+		return err
+	}
+
+	c := client.New(cfg.Get("URL"), cfg.Get("TOKEN"), cfg.Get("ORG_ID"))
+	return runCommand(ctx, c, fs.Args())
+}
+
+// lookupEnv looks up the environment variables of the settings, with their
+// prefix. The configuration file defaults to todo/config.yaml of the user
+// configuration directory, when it exists.
+func lookupEnv(name string) (string, bool) {
+	if val, ok := os.LookupEnv(envPrefix + name); ok || name != config.FileSetting {
+		return val, ok
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	path := filepath.Join(dir, "todo", "config.yaml")
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// exitCode returns the exit code of an error, told by the status of error
+// responses.
+func exitCode(err error) int {
+	var usage usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return exitError
+	}
+
+	switch {
+	case apiErr.Status == http.StatusNotFound:
+		return exitNotFound
+	case apiErr.Status == http.StatusUnauthorized, apiErr.Status == http.StatusForbidden:
+		return exitUnauthorized
+	case apiErr.Status == http.StatusBadRequest:
+		return exitInvalid
+	case apiErr.Status == http.StatusTooManyRequests:
+		return exitRateLimited
+	case apiErr.Status >= http.StatusInternalServerError:
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
+
+// printUsage prints the commands and the settings.
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: todo [flags] command [flags] [args]\n\nCommands:")
+	for _, c := range sortedCommands() {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", c.usage(), c.Usage)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFlags are also read from %sNAME variables, such as %sURL, and from the\n", envPrefix, envPrefix)
+	fmt.Fprintln(os.Stderr, "YAML or TOML file of --config-file, todo/config.yaml of the user configuration")
+	fmt.Fprintln(os.Stderr, "directory by default. Exit codes: 1 error, 2 usage, 3 not found, 4 unauthorized")
+	fmt.Fprintln(os.Stderr, "or forbidden, 5 invalid request, 6 rate limited, 7 server error or unavailable.")
+}
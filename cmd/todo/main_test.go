@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		code int
+	}{
+		"usage error":          {err: usageError{errors.New("test")}, code: exitUsage},
+		"wrapped usage error":  {err: fmt.Errorf("test: %w", usageError{errors.New("test")}), code: exitUsage},
+		"unexpected error":     {err: errors.New("test"), code: exitError},
+		"400 response":         {err: &client.Error{Status: http.StatusBadRequest}, code: exitInvalid},
+		"401 response":         {err: &client.Error{Status: http.StatusUnauthorized}, code: exitUnauthorized},
+		"403 response":         {err: &client.Error{Status: http.StatusForbidden}, code: exitUnauthorized},
+		"404 response":         {err: &client.Error{Status: http.StatusNotFound}, code: exitNotFound},
+		"409 response":         {err: &client.Error{Status: http.StatusConflict}, code: exitError},
+		"429 response":         {err: &client.Error{Status: http.StatusTooManyRequests}, code: exitRateLimited},
+		"500 response":         {err: &client.Error{Status: http.StatusInternalServerError}, code: exitUnavailable},
+		"wrapped 503 response": {err: fmt.Errorf("test: %w", &client.Error{Status: http.StatusServiceUnavailable}), code: exitUnavailable},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.code, exitCode(tc.err))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+// Output formats of todos.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// checkFormat checks the value of the --output flag, before calling the API.
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	default:
+		return usageError{fmt.Errorf("unknown output format: %s", format)}
+	}
+}
+
+// printTodos prints todos in the given format.
+func printTodos(w io.Writer, format string, todos []model.Todo) error {
+	switch format {
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tDONE\tCREATED\tMESSAGE")
+		for _, t := range todos {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.ID, done(t), t.CreatedAt.Local().Format("2006-01-02 15:04"), t.Message)
+		}
+		return tw.Flush()
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if todos == nil {
+			todos = []model.Todo{}
+		}
+		return enc.Encode(todos)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "created_at", "is_done", "completed_at", "archived_at", "owner_id", "message"})
+		for _, t := range todos {
+			_ = cw.Write([]string{
+				t.ID.String(),
+				t.CreatedAt.Format(time.RFC3339),
+				strconv.FormatBool(t.IsDone),
+				formatTime(t.CompletedAt),
+				formatTime(t.ArchivedAt),
+				t.OwnerID.String(),
+				t.Message,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return checkFormat(format)
+	}
+}
+
+// printChanges prints the todos added, removed or changed from prev to next.
+func printChanges(w io.Writer, prev, next []model.Todo) {
+	before := make(map[uuid.UUID]model.Todo, len(prev))
+	for _, t := range prev {
+		before[t.ID] = t
+	}
+
+	for _, t := range next {
+		old, ok := before[t.ID]
+		delete(before, t.ID)
+
+		switch {
+		case !ok:
+			fmt.Fprintf(w, "added\t%s\t%s\n", t.ID, t.Message)
+		case t.ArchivedAt != nil && old.ArchivedAt == nil:
+			fmt.Fprintf(w, "archived\t%s\t%s\n", t.ID, t.Message)
+		case t.IsDone && !old.IsDone:
+			fmt.Fprintf(w, "done\t%s\t%s\n", t.ID, t.Message)
+		case !t.IsDone && old.IsDone:
+			fmt.Fprintf(w, "reopened\t%s\t%s\n", t.ID, t.Message)
+		case t.Message != old.Message:
+			fmt.Fprintf(w, "updated\t%s\t%s\n", t.ID, t.Message)
+		}
+	}
+
+	// in the order of the previous list
+	for _, t := range prev {
+		if _, ok := before[t.ID]; ok {
+			fmt.Fprintf(w, "removed\t%s\t%s\n", t.ID, t.Message)
+		}
+	}
+}
+
+// done returns the state of a todo in tables.
+func done(t model.Todo) string {
+	switch {
+	case t.ArchivedAt != nil:
+		return "archived"
+	case t.IsDone:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
+// formatTime formats an optional time, empty when nil.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
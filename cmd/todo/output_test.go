@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	todoID  = uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5")
+	otherID = uuid.MustParse("b7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b")
+	ownerID = uuid.MustParse("3bc7a2f0-9c1d-4a5b-8e6f-7d8c9b0a1e2f")
+)
+
+func TestPrintTodos(t *testing.T) {
+	completed := time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC)
+	todos := []model.Todo{{
+		ID:          todoID,
+		CreatedAt:   time.Date(2023, 3, 1, 9, 30, 0, 0, time.UTC),
+		IsDone:      true,
+		Message:     "buy milk, eggs",
+		CompletedAt: &completed,
+		OwnerID:     ownerID,
+	}}
+
+	for name, tc := range map[string]struct {
+		format   string
+		todos    []model.Todo
+		expected string
+	}{
+		"CSV": {
+			format: formatCSV,
+			todos:  todos,
+			expected: "id,created_at,is_done,completed_at,archived_at,owner_id,message\n" +
+				"038863e4-2fbe-4bc3-9e38-1e62e93659f5,2023-03-01T09:30:00Z,true,2023-03-02T10:00:00Z,,3bc7a2f0-9c1d-4a5b-8e6f-7d8c9b0a1e2f,\"buy milk, eggs\"\n",
+		},
+		"CSV without todos": {
+			format:   formatCSV,
+			expected: "id,created_at,is_done,completed_at,archived_at,owner_id,message\n",
+		},
+		"JSON": {
+			format: formatJSON,
+			todos:  todos,
+			expected: `[
+  {
+    "id": "038863e4-2fbe-4bc3-9e38-1e62e93659f5",
+    "created_at": "2023-03-01T09:30:00Z",
+    "is_done": true,
+    "message": "buy milk, eggs",
+    "completed_at": "2023-03-02T10:00:00Z",
+    "owner_id": "3bc7a2f0-9c1d-4a5b-8e6f-7d8c9b0a1e2f"
+  }
+]
+`,
+		},
+		"JSON without todos": {
+			format:   formatJSON,
+			expected: "[]\n",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, printTodos(&buf, tc.format, tc.todos))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	t.Run("error on unknown format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := printTodos(&buf, "yaml", todos)
+		assert.ErrorAs(t, err, &usageError{})
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestPrintChanges(t *testing.T) {
+	archived := time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC)
+	todo := model.Todo{ID: todoID, Message: "test"}
+
+	for name, tc := range map[string]struct {
+		prev     []model.Todo
+		next     []model.Todo
+		expected string
+	}{
+		"no changes": {
+			prev: []model.Todo{todo},
+			next: []model.Todo{todo},
+		},
+		"added": {
+			next:     []model.Todo{todo},
+			expected: "added\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+		"removed": {
+			prev:     []model.Todo{todo},
+			expected: "removed\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+		"done": {
+			prev:     []model.Todo{todo},
+			next:     []model.Todo{{ID: todoID, Message: "test", IsDone: true}},
+			expected: "done\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+		"reopened": {
+			prev:     []model.Todo{{ID: todoID, Message: "test", IsDone: true}},
+			next:     []model.Todo{todo},
+			expected: "reopened\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+		"archived": {
+			prev:     []model.Todo{{ID: todoID, Message: "test", IsDone: true}},
+			next:     []model.Todo{{ID: todoID, Message: "test", IsDone: true, ArchivedAt: &archived}},
+			expected: "archived\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+		"updated": {
+			prev:     []model.Todo{todo},
+			next:     []model.Todo{{ID: todoID, Message: "other"}},
+			expected: "updated\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\tother\n",
+		},
+		"removed after the other changes": {
+			prev: []model.Todo{todo},
+			next: []model.Todo{{ID: otherID, Message: "other"}},
+			expected: "added\tb7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b\tother\n" +
+				"removed\t038863e4-2fbe-4bc3-9e38-1e62e93659f5\ttest\n",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printChanges(&buf, tc.prev, tc.next)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Optional checks non-empty values with check.
+func Optional(check func(string) error) func(string) error {
+	return func(val string) error {
+		if val == "" {
+			return nil
+		}
+		return check(val)
+	}
+}
+
+// Port checks a TCP port number.
+func Port(val string) error {
+	port, err := strconv.ParseUint(val, 10, 16)
+	if err != nil || port == 0 {
+		return fmt.Errorf("not a port number: %q", val)
+	}
+	return nil
+}
+
+// Duration checks a non-negative duration such as "1m30s".
+func Duration(val string) error {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return errors.New("negative duration")
+	}
+	return nil
+}
+
+// Uint checks a non-negative integer.
+func Uint(val string) error {
+	if _, err := strconv.ParseUint(val, 10, 32); err != nil {
+		return fmt.Errorf("not a non-negative integer: %q", val)
+	}
+	return nil
+}
+
+// OneOf checks that values are one of the given ones, ignoring case.
+func OneOf(vals ...string) func(string) error {
+	return func(val string) error {
+		for _, v := range vals {
+			if strings.EqualFold(v, val) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", val, strings.Join(vals, ", "))
+	}
+}
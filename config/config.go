@@ -0,0 +1,385 @@
+// Package config loads settings from a configuration file, environment
+// variables and command-line flags.
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileSetting names the environment variable, and the flag once lower cased,
+// holding the path of the configuration file.
+const FileSetting = "CONFIG_FILE"
+
+// Sources of setting values, by increasing precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Redacted replaces secret values when printed.
+const Redacted = "REDACTED"
+
+// fileSuffix ends the name of the settings holding the path of the file of
+// a secret setting.
+const fileSuffix = "_FILE"
+
+// Setting is a configurable value. It is named NAME in the environment,
+// name or nested sections such as name: {...} in the configuration file, and
+// --name in flags, lower cased with dashes.
+type Setting struct {
+	Name    string
+	Default string
+	Usage   string
+	// Check validates the value, when not nil.
+	Check func(string) error
+	// Secret settings are redacted when printed, and can be read from the
+	// file named by their NAME_FILE variant instead, such as a Docker or
+	// Kubernetes mounted secret.
+	Secret bool
+	// Redact returns the value to print in place of a secret one, when not
+	// nil. Non-empty secrets are replaced by Redacted otherwise.
+	Redact func(string) string
+	// Reloadable settings are applied again on reload, others require a
+	// restart.
+	Reloadable bool
+}
+
+// Change is a setting whose value differs between two configurations, with
+// secrets redacted.
+type Change struct {
+	Name       string
+	Old, New   string
+	Reloadable bool
+}
+
+// Config holds the values of settings.
+type Config struct {
+	settings []Setting
+	values   map[string]string
+	sources  map[string]string
+	// files are the paths of the secret settings read from files
+	files map[string]string
+}
+
+// Load resolves the value of the settings from their default, then the
+// configuration file, then the environment, then the command-line flags args.
+// The configuration file is a YAML or TOML file, told by its extension, whose
+// path is given by the --config-file flag or the CONFIG_FILE variable. Every
+// value is checked.
+func Load(settings []Setting, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg, rest, err := LoadCommand(settings, args, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+
+	return cfg, nil
+}
+
+// LoadCommand loads the settings like Load, flags ending at the first
+// argument that is not one, such as a subcommand. It returns the arguments
+// left.
+func LoadCommand(settings []Setting, args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	// secret settings are resolved along their file variant
+	all := settings
+	for _, s := range settings {
+		if s.Secret {
+			all = append(all[:len(all):len(all)], Setting{Name: s.Name + fileSuffix, Usage: "file holding the " + s.Name})
+		}
+	}
+
+	cfg := &Config{
+		settings: all,
+		values:   map[string]string{},
+		sources:  map[string]string{},
+		files:    map[string]string{},
+	}
+
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags := map[string]*string{}
+	file := fs.String(flagName(FileSetting), "", "YAML or TOML configuration file")
+	for _, s := range all {
+		cfg.set(s.Name, s.Default, SourceDefault)
+		flags[s.Name] = fs.String(flagName(s.Name), s.Default, s.Usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	path := *file
+	if path == "" {
+		path, _ = lookupEnv(FileSetting)
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for name, val := range values {
+			if !cfg.known(name) {
+				return nil, nil, fmt.Errorf("unknown setting %s in configuration file %s", strings.ToLower(name), path)
+			}
+			cfg.set(name, val, SourceFile)
+		}
+	}
+
+	for _, s := range all {
+		if val, ok := lookupEnv(s.Name); ok {
+			cfg.set(s.Name, val, SourceEnv)
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		for name, val := range flags {
+			if flagName(name) == f.Name {
+				cfg.set(name, *val, SourceFlag)
+			}
+		}
+	})
+
+	for _, s := range settings {
+		if s.Secret {
+			if err := cfg.readSecret(s.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	cfg.settings = settings
+
+	var errs []error
+	for _, s := range settings {
+		if s.Check == nil {
+			continue
+		}
+		if err := s.Check(cfg.values[s.Name]); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s from %s: %w", s.Name, cfg.sources[s.Name], err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// Get returns the value of the named setting.
+func (c *Config) Get(name string) string {
+	return c.values[name]
+}
+
+// Source returns where the value of the named setting comes from.
+func (c *Config) Source(name string) string {
+	return c.sources[name]
+}
+
+// Secret returns the provider of the named secret setting: its file when
+// read from one, the environment when set there, or its value.
+func (c *Config) Secret(name string) secret.Provider {
+	if path, ok := c.files[name]; ok {
+		return secret.File(path)
+	}
+	if c.sources[name] == SourceEnv {
+		return secret.Env(name)
+	}
+	return secret.Value(c.values[name])
+}
+
+// Diff returns the settings whose value differs from old to next, in the
+// order of the settings of next.
+func Diff(old, next *Config) []Change {
+	var changes []Change
+	for _, s := range next.settings {
+		prev, val := old.values[s.Name], next.values[s.Name]
+		if prev == val {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Name:       s.Name,
+			Old:        s.display(prev),
+			New:        s.display(val),
+			Reloadable: s.Reloadable,
+		})
+	}
+
+	return changes
+}
+
+// Print prints the settings as environment variables, with secrets redacted
+// and the source of their value.
+func (c *Config) Print(w io.Writer) error {
+	for _, s := range c.settings {
+		val := s.display(c.values[s.Name])
+
+		source := c.sources[s.Name]
+		if path, ok := c.files[s.Name]; ok {
+			source += ", read from " + path
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s # %s\n", s.Name, quote(val), source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// display returns the value to print, redacted when secret.
+func (s Setting) display(val string) string {
+	switch {
+	case s.Redact != nil:
+		return s.Redact(val)
+	case s.Secret && val != "":
+		return Redacted
+	default:
+		return val
+	}
+}
+
+// readSecret reads the named secret setting from the file of its file
+// variant, unless its value is set with a higher precedence.
+func (c *Config) readSecret(name string) error {
+	path, pathSource := c.values[name+fileSuffix], c.sources[name+fileSuffix]
+	if path == "" {
+		return nil
+	}
+
+	switch diff := rank(pathSource) - rank(c.sources[name]); {
+	case diff < 0:
+		return nil
+	case diff == 0:
+		return fmt.Errorf("both %s and %s%s are set from %s", name, name, fileSuffix, pathSource)
+	}
+
+	val, err := secret.File(path).Secret(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", name, err)
+	}
+
+	c.set(name, val, pathSource)
+	c.files[name] = path
+	return nil
+}
+
+// rank returns the precedence of a source.
+func rank(source string) int {
+	switch source {
+	case SourceFile:
+		return 1
+	case SourceEnv:
+		return 2
+	case SourceFlag:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func (c *Config) set(name, val, source string) {
+	c.values[name] = val
+	c.sources[name] = source
+}
+
+func (c *Config) known(name string) bool {
+	for _, s := range c.settings {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagName returns the flag of a setting.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// quote quotes values that a shell would split.
+func quote(val string) string {
+	if strings.ContainsAny(val, " \t\n\"'\\$`#") {
+		return fmt.Sprintf("%q", val)
+	}
+	return val
+}
+
+// readFile returns the settings of a configuration file, by name.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration file: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unknown configuration file format: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten(values, "", doc); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// flatten stores the values of a document into values, named by their path
+// in upper case, such as TLS_CERT_FILE for tls: {cert_file: ...}. Lists are
+// joined with commas.
+func flatten(values map[string]string, prefix string, doc map[string]any) error {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := strings.ToUpper(prefix + key)
+
+		switch val := doc[key].(type) {
+		case map[string]any:
+			if err := flatten(values, name+"_", val); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				if _, ok := item.(map[string]any); ok {
+					return fmt.Errorf("unexpected section in list %s", strings.ToLower(name))
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(val)
+		}
+	}
+
+	return nil
+}
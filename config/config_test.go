@@ -0,0 +1,198 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/config"
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var settings = []config.Setting{
+	{Name: "PORT", Default: "8080", Check: config.Port},
+	{Name: "LOGLEVEL", Default: "debug", Check: config.OneOf("info", "debug"), Reloadable: true},
+	{Name: "TLS_CERT_FILE"},
+	{Name: "TRUSTED_PROXIES"},
+	{Name: "DB_PASSWORD", Secret: true},
+}
+
+// env returns a lookup function of the given environment variables.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		val, ok := vars[name]
+		return val, ok
+	}
+}
+
+// writeFile writes data into a new file of the test temporary directory.
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("uses defaults", func(t *testing.T) {
+		cfg, err := config.Load(settings, nil, env(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "8080", cfg.Get("PORT"))
+		assert.Equal(t, config.SourceDefault, cfg.Source("PORT"))
+	})
+
+	t.Run("reads YAML files with nested sections and lists", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "port: 9090\ntls:\n  cert_file: server.crt\ntrusted_proxies: [10.0.0.0/8, 127.0.0.1]\n")
+
+		cfg, err := config.Load(settings, []string{"--config-file", path}, env(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "9090", cfg.Get("PORT"))
+		assert.Equal(t, "server.crt", cfg.Get("TLS_CERT_FILE"))
+		assert.Equal(t, "10.0.0.0/8,127.0.0.1", cfg.Get("TRUSTED_PROXIES"))
+		assert.Equal(t, config.SourceFile, cfg.Source("PORT"))
+	})
+
+	t.Run("reads TOML files", func(t *testing.T) {
+		path := writeFile(t, "config.toml", "port = 9090\n[tls]\ncert_file = \"server.crt\"\n")
+
+		cfg, err := config.Load(settings, nil, env(map[string]string{config.FileSetting: path}))
+		require.NoError(t, err)
+		assert.Equal(t, "9090", cfg.Get("PORT"))
+		assert.Equal(t, "server.crt", cfg.Get("TLS_CERT_FILE"))
+	})
+
+	t.Run("overrides the file with the environment and the environment with flags", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "port: 9090\nloglevel: info\ntls_cert_file: file.crt\n")
+
+		cfg, err := config.Load(settings, []string{"--config-file", path, "--tls-cert-file", "flag.crt"}, env(map[string]string{
+			"LOGLEVEL":      "debug",
+			"TLS_CERT_FILE": "env.crt",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "9090", cfg.Get("PORT"))
+		assert.Equal(t, "debug", cfg.Get("LOGLEVEL"))
+		assert.Equal(t, config.SourceEnv, cfg.Source("LOGLEVEL"))
+		assert.Equal(t, "flag.crt", cfg.Get("TLS_CERT_FILE"))
+		assert.Equal(t, config.SourceFlag, cfg.Source("TLS_CERT_FILE"))
+	})
+
+	t.Run("checks every value", func(t *testing.T) {
+		_, err := config.Load(settings, []string{"--port", "http"}, env(map[string]string{"LOGLEVEL": "verbose"}))
+		assert.ErrorContains(t, err, "invalid PORT from flag")
+		assert.ErrorContains(t, err, "invalid LOGLEVEL from env")
+	})
+
+	for name, tt := range map[string]struct {
+		file, data string
+	}{
+		"unknown setting":       {"config.yaml", "color: blue\n"},
+		"unknown format":        {"config.ini", "port=9090\n"},
+		"invalid document":      {"config.yaml", "port: [\n"},
+		"section in list":       {"config.yaml", "trusted_proxies: [{a: b}]\n"},
+		"invalid setting value": {"config.toml", "port = 0\n"},
+	} {
+		t.Run("error on "+name, func(t *testing.T) {
+			_, err := config.Load(settings, []string{"--config-file", writeFile(t, tt.file, tt.data)}, env(nil))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("error on missing file", func(t *testing.T) {
+		_, err := config.Load(settings, []string{"--config-file", filepath.Join(t.TempDir(), "missing.yaml")}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the arguments following the flags of a command", func(t *testing.T) {
+		cfg, rest, err := config.LoadCommand(settings, []string{"--port", "9090", "ls", "--all"}, env(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "9090", cfg.Get("PORT"))
+		assert.Equal(t, []string{"ls", "--all"}, rest)
+
+		_, err = config.Load(settings, []string{"--port", "9090", "ls"}, env(nil))
+		assert.ErrorContains(t, err, "unexpected arguments: ls")
+	})
+
+	t.Run("error on unknown flag", func(t *testing.T) {
+		_, err := config.Load(settings, []string{"--color", "blue"}, env(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadSecret(t *testing.T) {
+	t.Run("reads secrets from files", func(t *testing.T) {
+		path := writeFile(t, "db_password", "s3cret\n")
+
+		cfg, err := config.Load(settings, nil, env(map[string]string{"DB_PASSWORD_FILE": path}))
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Get("DB_PASSWORD"))
+		assert.Equal(t, secret.File(path), cfg.Secret("DB_PASSWORD"))
+	})
+
+	t.Run("reads file variants from configuration files", func(t *testing.T) {
+		path := writeFile(t, "db_password", "s3cret")
+		file := writeFile(t, "config.yaml", "db:\n  password_file: "+path+"\n")
+
+		cfg, err := config.Load(settings, []string{"--config-file", file}, env(nil))
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Get("DB_PASSWORD"))
+	})
+
+	t.Run("overrides the file variant with a value of higher precedence", func(t *testing.T) {
+		path := writeFile(t, "db_password", "s3cret")
+
+		cfg, err := config.Load(settings, []string{"--db-password", "flag"}, env(map[string]string{"DB_PASSWORD_FILE": path}))
+		require.NoError(t, err)
+		assert.Equal(t, "flag", cfg.Get("DB_PASSWORD"))
+		assert.Equal(t, secret.Value("flag"), cfg.Secret("DB_PASSWORD"))
+	})
+
+	t.Run("provides secrets of the environment", func(t *testing.T) {
+		cfg, err := config.Load(settings, nil, env(map[string]string{"DB_PASSWORD": "s3cret"}))
+		require.NoError(t, err)
+		assert.Equal(t, secret.Env("DB_PASSWORD"), cfg.Secret("DB_PASSWORD"))
+	})
+
+	t.Run("error on both value and file variant from the same source", func(t *testing.T) {
+		_, err := config.Load(settings, nil, env(map[string]string{"DB_PASSWORD": "s3cret", "DB_PASSWORD_FILE": "db_password"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("error on missing secret file", func(t *testing.T) {
+		_, err := config.Load(settings, nil, env(map[string]string{"DB_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")}))
+		assert.Error(t, err)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	old, err := config.Load(settings, []string{"--db-password", "old"}, env(nil))
+	require.NoError(t, err)
+
+	next, err := config.Load(settings, []string{"--db-password", "new", "--port", "9090", "--loglevel", "info"}, env(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []config.Change{
+		{Name: "PORT", Old: "8080", New: "9090"},
+		{Name: "LOGLEVEL", Old: "debug", New: "info", Reloadable: true},
+		{Name: "DB_PASSWORD", Old: config.Redacted, New: config.Redacted},
+	}, config.Diff(old, next))
+	assert.Empty(t, config.Diff(next, next))
+}
+
+func TestPrint(t *testing.T) {
+	cfg, err := config.Load(settings, []string{"--db-password", "s3cret", "--trusted-proxies", "a b"}, env(nil))
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, cfg.Print(&out))
+
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Equal(t, []string{
+		"PORT=8080 # default",
+		"LOGLEVEL=debug # default",
+		"TLS_CERT_FILE= # default",
+		`TRUSTED_PROXIES="a b" # flag`,
+		"DB_PASSWORD=REDACTED # flag",
+	}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
@@ -1,18 +1,70 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"time"
 )
 
-// Connect connects to a database and sets default options.
-func Connect(driver, dsn string) (*sql.DB, error) {
-	db, err := sql.Open(driver, dsn)
+// Connect connects to a database and sets default options. Connections are
+// renewed every few minutes, each new one reading the TLS files of the DSN
+// again, so that rotated client certificates are used without restart.
+func Connect(driverName, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("could not open DB connection pool: %w", err)
 	}
 
+	return setup(db)
+}
+
+// ConnectFunc connects like Connect, with the DSN returned by dsn for every
+// new connection, so that rotated credentials are used without restart.
+func ConnectFunc(driverName string, dsn func(context.Context) (string, error)) (*sql.DB, error) {
+	// the driver of a name is only exposed by the pools it opens
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not open DB connection pool: %w", err)
+	}
+	drv := db.Driver()
+	_ = db.Close()
+
+	return setup(sql.OpenDB(dsnConnector{driver: drv, dsn: dsn}))
+}
+
+// dsnConnector opens connections with the current DSN.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    func(context.Context) (string, error)
+}
+
+// Connect implements driver.Connector.
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.dsn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if drv, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := drv.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+
+	return c.driver.Open(dsn)
+}
+
+// Driver implements driver.Connector.
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// setup pings the database and sets default options of the pool.
+func setup(db *sql.DB) (*sql.DB, error) {
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("could not ping DB: %w", err)
 	}
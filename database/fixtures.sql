@@ -1,3 +1,31 @@
-INSERT INTO "todo" ("id", "created_at", "is_done", "message") VALUES
-('038863e4-2fbe-4bc3-9e38-1e62e93659f5', '2023-03-06 12:00:00.000000+00', FALSE, 'Test'),
-('169e84e3-35d9-4476-8295-2c28c54d50fc', '2023-03-06 14:00:00.000000+00', TRUE, 'Lorem ipsum');
+-- Rows are inserted in the organization of the connection, see the
+-- 006_organizations.sql migration.
+SELECT set_config('app.current_org', '00000000-0000-0000-0000-000000000001', FALSE);
+
+-- Both users have "password" as password.
+INSERT INTO "users" ("id", "created_at", "email", "password_hash") VALUES
+('7c9e6679-7425-40de-944b-e07fc1f90ae7', '2023-03-01 12:00:00.000000+00', 'john@example.com', '$argon2id$v=19$m=65536,t=3,p=4$IccNhp0ITxL5aL9HNDbhLg$IZhRvJI6QIW1Bf00JwOoXI1MrIW/JxSPyY/MEqc4szM'),
+('9b2f4c1e-3d5a-4e8b-8f7c-1a2b3c4d5e6f', '2023-03-01 13:00:00.000000+00', 'jane@example.com', '$argon2id$v=19$m=65536,t=3,p=4$IccNhp0ITxL5aL9HNDbhLg$IZhRvJI6QIW1Bf00JwOoXI1MrIW/JxSPyY/MEqc4szM');
+
+INSERT INTO "todo" ("id", "created_at", "is_done", "message", "completed_at", "archived_at", "owner_id") VALUES
+('038863e4-2fbe-4bc3-9e38-1e62e93659f5', '2023-03-06 12:00:00.000000+00', FALSE, 'Test', NULL, NULL, '7c9e6679-7425-40de-944b-e07fc1f90ae7'),
+('169e84e3-35d9-4476-8295-2c28c54d50fc', '2023-03-06 14:00:00.000000+00', TRUE, 'Lorem ipsum', '2023-03-06 14:00:00.000000+00', NULL, '7c9e6679-7425-40de-944b-e07fc1f90ae7'),
+('5d1b0e5c-0f0a-4d49-9a3c-3b4cdb0c7a1e', '2023-01-02 09:00:00.000000+00', TRUE, 'Archived', '2023-01-02 09:00:00.000000+00', '2023-02-01 00:00:00.000000+00', '7c9e6679-7425-40de-944b-e07fc1f90ae7'),
+('e1d2c3b4-a596-4877-8695-a4b3c2d1e0f9', '2023-03-06 13:00:00.000000+00', FALSE, 'Jane''s', NULL, NULL, '9b2f4c1e-3d5a-4e8b-8f7c-1a2b3c4d5e6f');
+
+-- Jane can view the todos of John.
+INSERT INTO "list_member" ("owner_id", "user_id", "role", "invited_at", "accepted_at") VALUES
+('7c9e6679-7425-40de-944b-e07fc1f90ae7', '9b2f4c1e-3d5a-4e8b-8f7c-1a2b3c4d5e6f', 'viewer', '2023-03-02 12:00:00.000000+00', '2023-03-02 13:00:00.000000+00');
+
+-- Acme is another organization, none of its rows are visible to the default one.
+INSERT INTO "organization" ("id", "created_at", "name") VALUES
+('a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a', '2023-03-01 00:00:00.000000+00', 'Acme');
+
+SELECT set_config('app.current_org', 'a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a', FALSE);
+
+-- Acme has its own John, with "password" as password.
+INSERT INTO "users" ("id", "created_at", "email", "password_hash") VALUES
+('4f6d8b2a-1c3e-4a5b-8d7f-9e0a1b2c3d4e', '2023-03-01 12:00:00.000000+00', 'john@example.com', '$argon2id$v=19$m=65536,t=3,p=4$IccNhp0ITxL5aL9HNDbhLg$IZhRvJI6QIW1Bf00JwOoXI1MrIW/JxSPyY/MEqc4szM');
+
+INSERT INTO "todo" ("id", "created_at", "is_done", "message", "completed_at", "archived_at", "owner_id") VALUES
+('b7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b', '2023-03-06 12:00:00.000000+00', TRUE, 'Acme', '2023-03-06 12:00:00.000000+00', NULL, '4f6d8b2a-1c3e-4a5b-8d7f-9e0a1b2c3d4e');
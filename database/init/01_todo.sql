@@ -0,0 +1,4 @@
+-- Superusers bypass row level security: the application connects as a
+-- regular user owning its database.
+CREATE ROLE todo LOGIN PASSWORD 'todo';
+CREATE DATABASE todo OWNER todo;
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations are the migrations of the schema, in the format of tern:
+// NNN_name.sql files whose up and down statements are separated by
+// dropSeparator.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// dropSeparator separates the statements migrating up from the ones
+// migrating down.
+const dropSeparator = "---- create above / drop below ----"
+
+// migrationLock is the advisory lock held while migrating, the one of tern so
+// that both never run at the same time.
+const migrationLock = int64(9628173550095224)
+
+// Migration is a version of the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// Down is empty when the migration can not be reverted.
+	Down string
+}
+
+// LoadMigrations returns the migrations of the .sql files of the migrations
+// directory of fsys, numbered from 1 without gap.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, p := range paths {
+		name := strings.TrimSuffix(path.Base(p), ".sql")
+		prefix, rest, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name: %s", p)
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read migration: %w", err)
+		}
+		up, down, _ := strings.Cut(string(data), dropSeparator)
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    rest,
+			Up:      strings.TrimSpace(up),
+			Down:    strings.TrimSpace(down),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("missing migration %d", i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrator migrates the schema of a database, recording its version in the
+// schema_version table like tern, so that databases migrated by either are
+// migrated by the other.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator of db to the migrations.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrations returns the migrations, by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Version returns the version of the schema, zero when never migrated.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	return SchemaVersionOf(ctx, m.db)
+}
+
+// MigrateTo migrates the schema up or down to version, zero dropping every
+// migration. Each migration runs in its own transaction, and an advisory lock
+// makes concurrent migrators wait for each other. The migrations applied
+// before an error are kept.
+func (m *Migrator) MigrateTo(ctx context.Context, version int) error {
+	if version < 0 || version > len(m.migrations) {
+		return fmt.Errorf("unknown schema version %d, the last one is %d", version, len(m.migrations))
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get DB connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		return fmt.Errorf("could not lock migrations: %w", err)
+	}
+	defer func() {
+		// released with the connection otherwise
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLock)
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (version INT4 NOT NULL);
+		INSERT INTO schema_version (version) SELECT 0 WHERE NOT EXISTS (SELECT 1 FROM schema_version);
+	`); err != nil {
+		return fmt.Errorf("could not create schema_version table: %w", err)
+	}
+
+	// read once locked, another migrator may have run meanwhile
+	var current int
+	if err := conn.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&current); err != nil {
+		return fmt.Errorf("could not get schema version: %w", err)
+	}
+	if current > len(m.migrations) {
+		return fmt.Errorf("schema version %d is newer than the last migration %d", current, len(m.migrations))
+	}
+
+	for current < version {
+		mig := m.migrations[current]
+		if err := step(ctx, conn, mig.Up, mig.Version); err != nil {
+			return fmt.Errorf("could not migrate up to %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		current++
+	}
+
+	for current > version {
+		mig := m.migrations[current-1]
+		if mig.Down == "" {
+			return fmt.Errorf("migration %d_%s can not be reverted", mig.Version, mig.Name)
+		}
+		if err := step(ctx, conn, mig.Down, mig.Version-1); err != nil {
+			return fmt.Errorf("could not migrate down from %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		current--
+	}
+
+	return nil
+}
+
+// MigrateUp migrates the schema to the last version.
+func (m *Migrator) MigrateUp(ctx context.Context) error {
+	return m.MigrateTo(ctx, len(m.migrations))
+}
+
+// step runs the statements of a migration then records the version it
+// migrates to, in a transaction.
+func step(ctx context.Context, conn *sql.Conn, statements string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE schema_version SET version = $1`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := database.Connect("pgx", "host=localhost user=todo password=todo")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	migrations, err := database.LoadMigrations(database.Migrations)
+	require.NoError(t, err)
+	m := database.NewMigrator(conn, migrations)
+
+	// the database is migrated by server migrate up, see make migrate-up
+	version, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, database.SchemaVersion, version)
+
+	t.Run("migrates down then up again", func(t *testing.T) {
+		require.NoError(t, m.MigrateTo(ctx, database.SchemaVersion-1))
+		version, err := m.Version(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, database.SchemaVersion-1, version)
+
+		require.NoError(t, m.MigrateUp(ctx))
+		assert.NoError(t, database.CheckSchema(ctx, conn))
+	})
+
+	t.Run("does nothing when up to date", func(t *testing.T) {
+		assert.NoError(t, m.MigrateUp(ctx))
+		assert.NoError(t, database.CheckSchema(ctx, conn))
+	})
+
+	t.Run("error on a schema ahead", func(t *testing.T) {
+		_, err := conn.ExecContext(ctx, `UPDATE schema_version SET version = version + 1`)
+		require.NoError(t, err)
+		defer func() {
+			_, err := conn.ExecContext(ctx, `UPDATE schema_version SET version = version - 1`)
+			require.NoError(t, err)
+		}()
+
+		assert.ErrorIs(t, database.CheckSchema(ctx, conn), database.ErrSchemaAhead)
+	})
+
+	t.Run("error on unknown version", func(t *testing.T) {
+		assert.Error(t, m.MigrateTo(ctx, database.SchemaVersion+1))
+	})
+}
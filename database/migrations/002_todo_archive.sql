@@ -0,0 +1,15 @@
+ALTER TABLE todo
+    ADD COLUMN completed_at TIMESTAMPTZ,
+    ADD COLUMN archived_at  TIMESTAMPTZ;
+
+UPDATE todo SET completed_at = created_at WHERE is_done;
+
+CREATE INDEX todo_archived_at_idx ON todo (archived_at);
+
+---- create above / drop below ----
+
+DROP INDEX todo_archived_at_idx;
+
+ALTER TABLE todo
+    DROP COLUMN archived_at,
+    DROP COLUMN completed_at;
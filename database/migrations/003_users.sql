@@ -0,0 +1,30 @@
+CREATE TABLE users (
+    id            UUID NOT NULL PRIMARY KEY,
+    created_at    TIMESTAMPTZ NOT NULL,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL
+);
+
+CREATE TABLE session (
+    token_hash BYTEA NOT NULL PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX session_user_id_idx ON session (user_id);
+
+-- Todos created before this migration have no owner and are not visible to anyone.
+ALTER TABLE todo ADD COLUMN owner_id UUID;
+
+CREATE INDEX todo_owner_id_idx ON todo (owner_id, created_at);
+
+---- create above / drop below ----
+
+DROP INDEX todo_owner_id_idx;
+
+ALTER TABLE todo DROP COLUMN owner_id;
+
+DROP TABLE session;
+
+DROP TABLE users;
@@ -0,0 +1,18 @@
+CREATE TABLE api_key (
+    id           UUID NOT NULL PRIMARY KEY,
+    owner_id     UUID NOT NULL,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     BYTEA NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX api_key_owner_id_idx ON api_key (owner_id, created_at);
+
+---- create above / drop below ----
+
+DROP TABLE api_key;
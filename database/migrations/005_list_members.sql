@@ -0,0 +1,17 @@
+-- A todo list is the set of todos of an owner. It is shared with other users
+-- once they accept the invitation.
+CREATE TABLE list_member (
+    owner_id    UUID NOT NULL,
+    user_id     UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role        TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+    invited_at  TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    PRIMARY KEY (owner_id, user_id),
+    CHECK (owner_id <> user_id)
+);
+
+CREATE INDEX list_member_user_id_idx ON list_member (user_id);
+
+---- create above / drop below ----
+
+DROP TABLE list_member;
@@ -0,0 +1,91 @@
+-- Every row belongs to an organization. Row level security restricts queries
+-- to the organization set in the app.current_org setting, so that a query
+-- forgetting to filter by organization does not leak other tenants' rows.
+-- Owners of tables bypass row level security unless it is forced, and
+-- superusers always bypass it: the application must not connect as one.
+CREATE TABLE organization (
+    id         UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    name       TEXT NOT NULL
+);
+
+-- Rows created before this migration belong to the default organization.
+INSERT INTO organization (id, created_at, name) VALUES ('00000000-0000-0000-0000-000000000001', NOW(), 'Default');
+
+-- current_org returns the organization of the connection, NULL when not set.
+CREATE FUNCTION current_org() RETURNS UUID
+    LANGUAGE SQL STABLE
+    AS $$ SELECT NULLIF(current_setting('app.current_org', TRUE), '')::UUID $$;
+
+ALTER TABLE users ADD COLUMN org_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organization (id);
+ALTER TABLE users ALTER COLUMN org_id SET DEFAULT current_org();
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_org_id_email_key UNIQUE (org_id, email);
+
+ALTER TABLE session ADD COLUMN org_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organization (id);
+ALTER TABLE session ALTER COLUMN org_id SET DEFAULT current_org();
+
+ALTER TABLE todo ADD COLUMN org_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organization (id);
+ALTER TABLE todo ALTER COLUMN org_id SET DEFAULT current_org();
+
+ALTER TABLE api_key ADD COLUMN org_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organization (id);
+ALTER TABLE api_key ALTER COLUMN org_id SET DEFAULT current_org();
+
+ALTER TABLE list_member ADD COLUMN org_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organization (id);
+ALTER TABLE list_member ALTER COLUMN org_id SET DEFAULT current_org();
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+CREATE POLICY users_org_isolation ON users USING (org_id = current_org());
+
+ALTER TABLE session ENABLE ROW LEVEL SECURITY;
+ALTER TABLE session FORCE ROW LEVEL SECURITY;
+CREATE POLICY session_org_isolation ON session USING (org_id = current_org());
+
+ALTER TABLE todo ENABLE ROW LEVEL SECURITY;
+ALTER TABLE todo FORCE ROW LEVEL SECURITY;
+CREATE POLICY todo_org_isolation ON todo USING (org_id = current_org());
+
+ALTER TABLE api_key ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_key FORCE ROW LEVEL SECURITY;
+CREATE POLICY api_key_org_isolation ON api_key USING (org_id = current_org());
+
+ALTER TABLE list_member ENABLE ROW LEVEL SECURITY;
+ALTER TABLE list_member FORCE ROW LEVEL SECURITY;
+CREATE POLICY list_member_org_isolation ON list_member USING (org_id = current_org());
+
+---- create above / drop below ----
+
+DROP POLICY list_member_org_isolation ON list_member;
+ALTER TABLE list_member NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE list_member DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY api_key_org_isolation ON api_key;
+ALTER TABLE api_key NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_key DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY todo_org_isolation ON todo;
+ALTER TABLE todo NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE todo DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY session_org_isolation ON session;
+ALTER TABLE session NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE session DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY users_org_isolation ON users;
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE list_member DROP COLUMN org_id;
+ALTER TABLE api_key DROP COLUMN org_id;
+ALTER TABLE todo DROP COLUMN org_id;
+ALTER TABLE session DROP COLUMN org_id;
+
+-- Fails when the same email is registered in several organizations.
+ALTER TABLE users DROP CONSTRAINT users_org_id_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users DROP COLUMN org_id;
+
+DROP FUNCTION current_org();
+
+DROP TABLE organization;
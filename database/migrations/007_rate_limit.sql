@@ -0,0 +1,49 @@
+-- Token buckets of the rate limiter, keyed by principal or client IP. They
+-- are not tenant data and are not restricted by row level security.
+CREATE TABLE rate_limit (
+    key        TEXT NOT NULL PRIMARY KEY,
+    tokens     FLOAT8 NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX rate_limit_updated_at_idx ON rate_limit (updated_at);
+
+-- rate_limit_take refills the bucket of the key, created full when missing,
+-- then takes a token from it when there is one left.
+CREATE FUNCTION rate_limit_take(p_key TEXT, p_burst FLOAT8, p_rate FLOAT8, OUT allowed BOOLEAN, OUT remaining FLOAT8)
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    ts      TIMESTAMPTZ;
+    updated TIMESTAMPTZ;
+BEGIN
+    INSERT INTO rate_limit (key, tokens, updated_at)
+    VALUES (p_key, p_burst, clock_timestamp())
+    ON CONFLICT (key) DO NOTHING;
+
+    SELECT r.tokens, r.updated_at
+    INTO remaining, updated
+    FROM rate_limit r
+    WHERE r.key = p_key
+    FOR UPDATE;
+
+    -- read once the row is locked, so that time does not go backwards
+    ts := clock_timestamp();
+    remaining := LEAST(p_burst, remaining + EXTRACT(EPOCH FROM ts - updated) * p_rate);
+
+    allowed := remaining >= 1;
+    IF allowed THEN
+        remaining := remaining - 1;
+    END IF;
+
+    UPDATE rate_limit
+    SET tokens = remaining, updated_at = ts
+    WHERE key = p_key;
+END
+$$;
+
+---- create above / drop below ----
+
+DROP FUNCTION rate_limit_take(TEXT, FLOAT8, FLOAT8);
+
+DROP TABLE rate_limit;
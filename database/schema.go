@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the version of the schema the application works with, the
+// number of the last migration of database/migrations.
+const SchemaVersion = 7
+
+// ErrSchemaBehind is returned when the schema of the database is older than
+// SchemaVersion, not migrated yet.
+var ErrSchemaBehind = errors.New("schema is behind")
+
+// ErrSchemaAhead is returned when the schema of the database is newer than
+// SchemaVersion, migrated for a newer binary still being rolled out.
+var ErrSchemaAhead = errors.New("schema is ahead")
+
+// SchemaVersionOf returns the version of the schema of the database, as
+// recorded in the schema_version table, zero when never migrated.
+func SchemaVersionOf(ctx context.Context, db *sql.DB) (int, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_version') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("could not get schema version: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("could not get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// CheckSchema returns an error when the schema of the database is not at
+// SchemaVersion, ErrSchemaBehind when older and ErrSchemaAhead when newer.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	version, err := SchemaVersionOf(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if version < SchemaVersion {
+		return fmt.Errorf("%w: version is %d, expected %d", ErrSchemaBehind, version, SchemaVersion)
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: version is %d, expected %d", ErrSchemaAhead, version, SchemaVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package database_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/iciantoine/todo-go-api/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	entries, err := os.ReadDir("migrations")
+	require.NoError(t, err)
+
+	last := 0
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if n, err := strconv.Atoi(prefix); ok && err == nil && n > last {
+			last = n
+		}
+	}
+
+	assert.Equal(t, last, database.SchemaVersion, "SchemaVersion must be the number of the last migration")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("loads the embedded migrations", func(t *testing.T) {
+		migrations, err := database.LoadMigrations(database.Migrations)
+		require.NoError(t, err)
+		require.Len(t, migrations, database.SchemaVersion)
+
+		assert.Equal(t, "todo_table", migrations[0].Name)
+		assert.Contains(t, migrations[0].Up, "CREATE TABLE todo")
+		assert.Equal(t, "DROP TABLE todo;", migrations[0].Down)
+	})
+
+	t.Run("orders migrations by version", func(t *testing.T) {
+		migrations, err := database.LoadMigrations(fstest.MapFS{
+			"migrations/010_b.sql": {Data: []byte("SELECT 10;")},
+			"migrations/2_a.sql":   {Data: []byte("SELECT 2;")},
+			"migrations/001_a.sql": {Data: []byte("SELECT 1;")},
+		})
+		assert.ErrorContains(t, err, "missing migration 3")
+		assert.Nil(t, migrations)
+
+		migrations, err = database.LoadMigrations(fstest.MapFS{
+			"migrations/2_b.sql":   {Data: []byte("SELECT 2;")},
+			"migrations/001_a.sql": {Data: []byte("SELECT 1;\n---- create above / drop below ----\nSELECT 0;")},
+			"migrations/tern.conf": {Data: []byte("[database]")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []database.Migration{
+			{Version: 1, Name: "a", Up: "SELECT 1;", Down: "SELECT 0;"},
+			{Version: 2, Name: "b", Up: "SELECT 2;"},
+		}, migrations)
+	})
+
+	t.Run("error on invalid name", func(t *testing.T) {
+		_, err := database.LoadMigrations(fstest.MapFS{"migrations/first.sql": {Data: []byte("SELECT 1;")}})
+		assert.Error(t, err)
+	})
+}
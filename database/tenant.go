@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOrg is the organization rows created before multi-tenancy belong to.
+var DefaultOrg = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type txKey struct{}
+
+// dbtx is what both a connection pool and a transaction can query with.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// OrgFunc returns the organization held by a context.
+type OrgFunc func(ctx context.Context) (uuid.UUID, bool)
+
+// OrgDB is a connection pool whose queries run in the transaction of Tx in
+// their context. Queries with any other context run on an arbitrary connection
+// of the pool, without organization, and see no rows of the tables with row
+// level security.
+type OrgDB struct {
+	db  *sql.DB
+	org OrgFunc
+}
+
+// NewOrgDB instantiates OrgDB, transactions running in the organization
+// returned by org.
+func NewOrgDB(db *sql.DB, org OrgFunc) OrgDB {
+	return OrgDB{
+		db:  db,
+		org: org,
+	}
+}
+
+// Tx runs fn in a transaction whose app.current_org setting is the
+// organization of ctx, so that row level security restricts its queries to
+// the rows of the organization. The setting is local to the transaction, and
+// the connection is only held until fn returns. Queries with the context given
+// to fn run in the transaction, committed once fn returns without error.
+// Nested calls run in the transaction of the outer one.
+func (o OrgDB) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if org, ok := o.org(ctx); ok {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('app.current_org', $1, TRUE)`, org.String()); err != nil {
+			return fmt.Errorf("could not set organization: %w", err)
+		}
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (o OrgDB) conn(ctx context.Context) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return o.db
+}
+
+// ExecContext implements repository.DBTX.
+func (o OrgDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return o.conn(ctx).ExecContext(ctx, query, args...)
+}
+
+// QueryRowContext implements repository.DBTX.
+func (o OrgDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return o.conn(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// QueryContext implements repository.DBTX.
+func (o OrgDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return o.conn(ctx).QueryContext(ctx, query, args...)
+}
+
+// PrepareContext implements repository.DBTX.
+func (o OrgDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return o.conn(ctx).PrepareContext(ctx, query)
+}
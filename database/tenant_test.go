@@ -0,0 +1,137 @@
+//go:build integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/database"
+	"github.com/iciantoine/todo-go-api/tenant"
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var acmeOrg = uuid.MustParse("a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a")
+
+func TestOrgDB(t *testing.T) {
+	conn, err := database.Connect("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := database.NewOrgDB(conn, tenant.OrgFrom)
+
+	count := func(ctx context.Context, table string) int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	// inOrg runs fn in a transaction of the organization
+	inOrg := func(org uuid.UUID, fn func(ctx context.Context) error) error {
+		return db.Tx(tenant.WithOrg(context.Background(), org), fn)
+	}
+
+	t.Run("queries only see the rows of the organization", func(t *testing.T) {
+		err := inOrg(database.DefaultOrg, func(ctx context.Context) error {
+			assert.Equal(t, 4, count(ctx, "todo"))
+			assert.Equal(t, 2, count(ctx, "users"))
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = inOrg(acmeOrg, func(ctx context.Context) error {
+			assert.Equal(t, 1, count(ctx, "todo"))
+			assert.Equal(t, 1, count(ctx, "users"))
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("cross-tenant reads fail", func(t *testing.T) {
+		err := inOrg(acmeOrg, func(ctx context.Context) error {
+			var message string
+			err := db.QueryRowContext(ctx, "SELECT message FROM todo WHERE id = '038863e4-2fbe-4bc3-9e38-1e62e93659f5'").Scan(&message)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("cross-tenant writes fail", func(t *testing.T) {
+		err := inOrg(acmeOrg, func(ctx context.Context) error {
+			res, err := db.ExecContext(ctx, "UPDATE todo SET message = 'test' WHERE id = '038863e4-2fbe-4bc3-9e38-1e62e93659f5'")
+			assert.NoError(t, err)
+			n, _ := res.RowsAffected()
+			assert.Zero(t, n)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		err = inOrg(acmeOrg, func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, `
+				INSERT INTO todo (id, created_at, is_done, message, owner_id, org_id)
+				VALUES ($1, NOW(), FALSE, 'test', $1, $2)
+			`, uuid.New(), database.DefaultOrg)
+			return err
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("queries without organization see no rows", func(t *testing.T) {
+		assert.Zero(t, count(context.Background(), "todo"))
+
+		err := db.Tx(context.Background(), func(ctx context.Context) error {
+			assert.Zero(t, count(ctx, "todo"))
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("connections do not keep the organization", func(t *testing.T) {
+		conn.SetMaxOpenConns(1)
+		defer conn.SetMaxOpenConns(25)
+
+		err := inOrg(database.DefaultOrg, func(ctx context.Context) error {
+			return nil
+		})
+		assert.NoError(t, err)
+
+		assert.Zero(t, count(context.Background(), "todo"))
+	})
+
+	t.Run("nested transactions run in the outer one", func(t *testing.T) {
+		conn.SetMaxOpenConns(1)
+		defer conn.SetMaxOpenConns(25)
+
+		err := inOrg(acmeOrg, func(ctx context.Context) error {
+			return db.Tx(ctx, func(ctx context.Context) error {
+				assert.Equal(t, 1, count(ctx, "todo"))
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("transactions are rolled back on error", func(t *testing.T) {
+		expected := errors.New("test")
+		err := inOrg(acmeOrg, func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, "UPDATE todo SET message = 'test' WHERE id = 'b7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b'")
+			assert.NoError(t, err)
+			return expected
+		})
+		assert.ErrorIs(t, err, expected)
+
+		err = inOrg(acmeOrg, func(ctx context.Context) error {
+			var message string
+			require.NoError(t, db.QueryRowContext(ctx, "SELECT message FROM todo WHERE id = 'b7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b'").Scan(&message))
+			assert.Equal(t, "Acme", message)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
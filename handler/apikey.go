@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type APIKeyRepo interface {
+	GetAPIKeys(ctx context.Context, owner uuid.UUID) ([]model.APIKey, error)
+	AddAPIKey(ctx context.Context, owner uuid.UUID, model model.APIKey) (model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, owner uuid.UUID, id uuid.UUID) error
+}
+
+func NewGetAPIKeysHandler(repo APIKeyRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		owner, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		res, err := repo.GetAPIKeys(ctx, owner)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting API keys")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func NewPostAPIKeyHandler(repo APIKeyRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		owner, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		var req model.APIKey
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		key, prefix, err := auth.NewAPIKey()
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while generating API key")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		req.Prefix = prefix
+		req.KeyHash = auth.HashToken(key)
+
+		res, err := repo.AddAPIKey(ctx, owner, req)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while adding API key")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		res.Key = key
+		ctx.JSON(http.StatusCreated, res)
+	}
+}
+
+func NewDeleteAPIKeyHandler(repo APIKeyRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		owner, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		id, err := uuid.Parse(ctx.Query("id"))
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse uuid")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		err = repo.RevokeAPIKey(ctx, owner, id)
+
+		switch {
+		case err == nil:
+			ctx.Status(http.StatusNoContent)
+		case errors.Is(err, repository.ErrAPIKeyNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Str("id", id.String()).Err(err).Msg("error while revoking API key")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
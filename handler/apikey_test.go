@@ -0,0 +1,220 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+type stubAPIKeyRepo struct {
+	key     model.APIKey
+	keyList []model.APIKey
+	err     error
+	owner   uuid.UUID
+	added   model.APIKey
+	revoked uuid.UUID
+}
+
+func (sr *stubAPIKeyRepo) GetAPIKeys(ctx context.Context, owner uuid.UUID) ([]model.APIKey, error) {
+	sr.owner = owner
+	return sr.keyList, sr.err
+}
+
+func (sr *stubAPIKeyRepo) AddAPIKey(ctx context.Context, owner uuid.UUID, model model.APIKey) (model.APIKey, error) {
+	sr.owner = owner
+	sr.added = model
+	return model, sr.err
+}
+
+func (sr *stubAPIKeyRepo) RevokeAPIKey(ctx context.Context, owner uuid.UUID, id uuid.UUID) error {
+	sr.owner = owner
+	sr.revoked = id
+	return sr.err
+}
+
+func TestNewGetAPIKeysHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/apikey", http.NoBody))
+
+		expected := []model.APIKey{
+			{
+				ID:        uuid.New(),
+				Name:      "CI",
+				Prefix:    "tdk_12345678",
+				Scopes:    []string{auth.ScopeTodoWrite},
+				CreatedAt: time.Now(),
+				OwnerID:   testOwner,
+			},
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubAPIKeyRepo{
+			keyList: expected,
+		}
+		hdlr := handler.NewGetAPIKeysHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/apikey", http.NoBody))
+
+		hdlr := handler.NewGetAPIKeysHandler(&stubAPIKeyRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestNewPostAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 201 with the key on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"name": "CI", "scopes": ["todo:read", "todo:write"]}`
+		ctx.Request = authenticate(http.NewRequest("POST", "/apikey", bytes.NewReader([]byte(payload))))
+
+		repo := &stubAPIKeyRepo{}
+		hdlr := handler.NewPostAPIKeyHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		key := gjson.GetBytes(body, "key").String()
+		assert.Equal(t, key[:12], gjson.GetBytes(body, "prefix").String())
+		assert.Equal(t, auth.HashToken(key), repo.added.KeyHash)
+		assert.Equal(t, []string{auth.ScopeTodoRead, auth.ScopeTodoWrite}, repo.added.Scopes)
+		assert.Equal(t, testOwner, repo.owner)
+		assert.NotContains(t, string(body), "key_hash")
+	})
+
+	for name, payload := range map[string]string{
+		"without name":    `{"scopes": ["todo:read"]}`,
+		"without scopes":  `{"name": "CI", "scopes": []}`,
+		"unknown scope":   `{"name": "CI", "scopes": ["todo:delete"]}`,
+		"past expiration": fmt.Sprintf(`{"name": "CI", "scopes": ["todo:read"], "expires_at": %q}`, time.Now().Add(-time.Hour).Format(time.RFC3339)),
+	} {
+		payload := payload
+		t.Run("returns 400 on payload "+name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("POST", "/apikey", bytes.NewReader([]byte(payload))))
+
+			hdlr := handler.NewPostAPIKeyHandler(&stubAPIKeyRepo{})
+			hdlr(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"name": "CI", "scopes": ["todo:read"]}`
+		ctx.Request = authenticate(http.NewRequest("POST", "/apikey", bytes.NewReader([]byte(payload))))
+
+		hdlr := handler.NewPostAPIKeyHandler(&stubAPIKeyRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, body)
+	})
+}
+
+func TestNewDeleteAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 204 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		id := uuid.New()
+		ctx.Request = authenticate(http.NewRequest("DELETE", fmt.Sprintf("/apikey?id=%s", id), http.NoBody))
+
+		repo := &stubAPIKeyRepo{}
+		hdlr := handler.NewDeleteAPIKeyHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNoContent, ctx.Writer.Status())
+		assert.Equal(t, id, repo.revoked)
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("returns 400 on non-valid UUID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("DELETE", "/apikey?id=1234", http.NoBody))
+
+		hdlr := handler.NewDeleteAPIKeyHandler(&stubAPIKeyRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 404 on non existing API key", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("DELETE", fmt.Sprintf("/apikey?id=%s", uuid.New()), http.NoBody))
+
+		hdlr := handler.NewDeleteAPIKeyHandler(&stubAPIKeyRepo{
+			err: repository.ErrAPIKeyNotFound,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("DELETE", fmt.Sprintf("/apikey?id=%s", uuid.New()), http.NoBody))
+
+		hdlr := handler.NewDeleteAPIKeyHandler(&stubAPIKeyRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/rs/zerolog/log"
+)
+
+func NewGetArchivedTodosHandler(repo TodoRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, ok := listOwner(ctx, user)
+		if !ok {
+			return
+		}
+
+		res, err := repo.GetArchivedTodos(ctx, user, owner)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, res)
+		case errors.Is(err, repository.ErrListNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting archived todos")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
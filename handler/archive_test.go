@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGetArchivedTodosHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/archive", http.NoBody))
+
+		now := time.Now()
+		expected := []model.Todo{
+			{
+				ID:          uuid.New(),
+				CreatedAt:   now,
+				IsDone:      true,
+				Message:     "Lorem ipsum",
+				CompletedAt: &now,
+				ArchivedAt:  &now,
+			},
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubRepo{
+			todoList: expected,
+		}
+		hdlr := handler.NewGetArchivedTodosHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/archive", http.NoBody))
+
+		hdlr := handler.NewGetArchivedTodosHandler(&stubRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, body)
+	})
+}
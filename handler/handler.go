@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/rs/zerolog/log"
+)
+
+// owner returns the authenticated user the request acts on behalf of, or
+// aborts the request.
+func owner(ctx *gin.Context) (uuid.UUID, bool) {
+	p, ok := auth.PrincipalFrom(ctx.Request.Context())
+	if !ok {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	return p.UserID, true
+}
+
+// listOwner returns the owner of the todo list the request acts on, given by
+// the owner_id query parameter and defaulting to the user, or aborts the
+// request.
+func listOwner(ctx *gin.Context, user uuid.UUID) (uuid.UUID, bool) {
+	id, exists := ctx.GetQuery("owner_id")
+	if !exists {
+		return user, true
+	}
+
+	res, err := uuid.Parse(id)
+	if err != nil {
+		log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse owner_id")
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return res, true
+}
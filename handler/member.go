@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type MemberRepo interface {
+	GetMembers(ctx context.Context, user, owner uuid.UUID) ([]model.Member, error)
+	InviteMember(ctx context.Context, user, owner uuid.UUID, model model.Member) (model.Member, error)
+	RemoveMember(ctx context.Context, user, owner, member uuid.UUID) error
+	GetInvitations(ctx context.Context, user uuid.UUID) ([]model.Member, error)
+	AcceptInvitation(ctx context.Context, user, owner uuid.UUID) error
+	LeaveList(ctx context.Context, user, owner uuid.UUID) error
+}
+
+func NewGetMembersHandler(repo MemberRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, ok := listOwner(ctx, user)
+		if !ok {
+			return
+		}
+
+		res, err := repo.GetMembers(ctx, user, owner)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, res)
+		case errors.Is(err, repository.ErrListNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting members")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
+
+func NewPostMemberHandler(repo MemberRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, ok := listOwner(ctx, user)
+		if !ok {
+			return
+		}
+
+		var req model.Member
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		req.Email = normalizeEmail(req.Email)
+
+		res, err := repo.InviteMember(ctx, user, owner, req)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusCreated, res)
+		case errors.Is(err, repository.ErrListNotFound), errors.Is(err, repository.ErrUserNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, authz.ErrForbidden):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while inviting member")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
+
+func NewDeleteMemberHandler(repo MemberRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, ok := listOwner(ctx, user)
+		if !ok {
+			return
+		}
+
+		member, err := uuid.Parse(ctx.Query("user_id"))
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse uuid")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		err = repo.RemoveMember(ctx, user, owner, member)
+
+		switch {
+		case err == nil:
+			ctx.Status(http.StatusNoContent)
+		case errors.Is(err, repository.ErrListNotFound), errors.Is(err, repository.ErrMemberNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, authz.ErrForbidden):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Str("user_id", member.String()).Err(err).Msg("error while removing member")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
+
+func NewGetInvitationsHandler(repo MemberRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		res, err := repo.GetInvitations(ctx, user)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting invitations")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func NewAcceptInvitationHandler(repo MemberRepo) gin.HandlerFunc {
+	return newInvitationHandler(repo.AcceptInvitation, "error while accepting invitation")
+}
+
+func NewDeleteInvitationHandler(repo MemberRepo) gin.HandlerFunc {
+	return newInvitationHandler(repo.LeaveList, "error while leaving list")
+}
+
+// newInvitationHandler answers the invitation of the user to the list given
+// by the owner_id query parameter.
+func newInvitationHandler(answer func(ctx context.Context, user, owner uuid.UUID) error, msg string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, err := uuid.Parse(ctx.Query("owner_id"))
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse uuid")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		err = answer(ctx, user, owner)
+
+		switch {
+		case err == nil:
+			ctx.Status(http.StatusNoContent)
+		case errors.Is(err, repository.ErrMemberNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Str("owner_id", owner.String()).Err(err).Msg(msg)
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
+	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubMemberRepo struct {
+	member     model.Member
+	memberList []model.Member
+	err        error
+	user       uuid.UUID
+	owner      uuid.UUID
+	invited    model.Member
+	removed    uuid.UUID
+	answered   string
+}
+
+func (sr *stubMemberRepo) GetMembers(ctx context.Context, user, owner uuid.UUID) ([]model.Member, error) {
+	sr.user, sr.owner = user, owner
+	return sr.memberList, sr.err
+}
+
+func (sr *stubMemberRepo) InviteMember(ctx context.Context, user, owner uuid.UUID, model model.Member) (model.Member, error) {
+	sr.user, sr.owner = user, owner
+	sr.invited = model
+	return sr.member, sr.err
+}
+
+func (sr *stubMemberRepo) RemoveMember(ctx context.Context, user, owner, member uuid.UUID) error {
+	sr.user, sr.owner = user, owner
+	sr.removed = member
+	return sr.err
+}
+
+func (sr *stubMemberRepo) GetInvitations(ctx context.Context, user uuid.UUID) ([]model.Member, error) {
+	sr.user = user
+	return sr.memberList, sr.err
+}
+
+func (sr *stubMemberRepo) AcceptInvitation(ctx context.Context, user, owner uuid.UUID) error {
+	sr.user, sr.owner = user, owner
+	sr.answered = "accepted"
+	return sr.err
+}
+
+func (sr *stubMemberRepo) LeaveList(ctx context.Context, user, owner uuid.UUID) error {
+	sr.user, sr.owner = user, owner
+	sr.answered = "left"
+	return sr.err
+}
+
+func TestNewGetMembersHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/member", http.NoBody))
+
+		expected := []model.Member{
+			{
+				OwnerID:   testOwner,
+				UserID:    uuid.New(),
+				Email:     "jane@example.com",
+				Role:      "viewer",
+				InvitedAt: time.Now(),
+			},
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubMemberRepo{
+			memberList: expected,
+		}
+		hdlr := handler.NewGetMembersHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.user)
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("returns 404 when the list is not shared with the user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/member?owner_id=%s", uuid.New()), http.NoBody))
+
+		hdlr := handler.NewGetMembersHandler(&stubMemberRepo{
+			err: repository.ErrListNotFound,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/member", http.NoBody))
+
+		hdlr := handler.NewGetMembersHandler(&stubMemberRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestNewPostMemberHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 201 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("POST", "/member",
+			bytes.NewReader([]byte(`{"email": "Jane@Example.com", "role": "editor"}`))))
+
+		repo := &stubMemberRepo{}
+		hdlr := handler.NewPostMemberHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, testOwner, repo.owner)
+		assert.Equal(t, "jane@example.com", repo.invited.Email)
+		assert.Equal(t, "editor", repo.invited.Role)
+	})
+
+	for name, payload := range map[string]string{
+		"without email":   `{"role": "viewer"}`,
+		"with wrong role": `{"email": "jane@example.com", "role": "owner"}`,
+	} {
+		t.Run("returns 400 on payload "+name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("POST", "/member", bytes.NewReader([]byte(payload))))
+
+			hdlr := handler.NewPostMemberHandler(&stubMemberRepo{})
+			hdlr(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	errs := map[error]int{
+		repository.ErrUserNotFound: http.StatusNotFound,
+		repository.ErrListNotFound: http.StatusNotFound,
+		authz.ErrForbidden:         http.StatusForbidden,
+		errors.New("test"):         http.StatusInternalServerError,
+	}
+
+	for err, status := range errs {
+		t.Run(fmt.Sprintf("returns %d on %s error", status, err), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("POST", "/member",
+				bytes.NewReader([]byte(`{"email": "jane@example.com", "role": "viewer"}`))))
+
+			hdlr := handler.NewPostMemberHandler(&stubMemberRepo{
+				err: err,
+			})
+			hdlr(ctx)
+
+			assert.Equal(t, status, rr.Code)
+		})
+	}
+}
+
+func TestNewDeleteMemberHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 204 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		member := uuid.New()
+		ctx.Request = authenticate(http.NewRequest("DELETE", fmt.Sprintf("/member?user_id=%s", member), http.NoBody))
+
+		repo := &stubMemberRepo{}
+		hdlr := handler.NewDeleteMemberHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNoContent, ctx.Writer.Status())
+		assert.Equal(t, testOwner, repo.owner)
+		assert.Equal(t, member, repo.removed)
+	})
+
+	t.Run("returns 400 on non-valid UUID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("DELETE", "/member?user_id=1234", http.NoBody))
+
+		hdlr := handler.NewDeleteMemberHandler(&stubMemberRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	errs := map[error]int{
+		repository.ErrMemberNotFound: http.StatusNotFound,
+		repository.ErrListNotFound:   http.StatusNotFound,
+		authz.ErrForbidden:           http.StatusForbidden,
+		errors.New("test"):           http.StatusInternalServerError,
+	}
+
+	for err, status := range errs {
+		t.Run(fmt.Sprintf("returns %d on %s error", status, err), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("DELETE", fmt.Sprintf("/member?user_id=%s", uuid.New()), http.NoBody))
+
+			hdlr := handler.NewDeleteMemberHandler(&stubMemberRepo{
+				err: err,
+			})
+			hdlr(ctx)
+
+			assert.Equal(t, status, rr.Code)
+		})
+	}
+}
+
+func TestNewGetInvitationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/invitation", http.NoBody))
+
+		repo := &stubMemberRepo{
+			memberList: []model.Member{},
+		}
+		hdlr := handler.NewGetInvitationsHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, testOwner, repo.user)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/invitation", http.NoBody))
+
+		hdlr := handler.NewGetInvitationsHandler(&stubMemberRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestInvitationHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handlers := map[string]func(handler.MemberRepo) gin.HandlerFunc{
+		"accepted": handler.NewAcceptInvitationHandler,
+		"left":     handler.NewDeleteInvitationHandler,
+	}
+
+	for answer, newHandler := range handlers {
+		t.Run("returns 204 on successful call when "+answer, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			owner := uuid.New()
+			ctx.Request = authenticate(http.NewRequest("POST", fmt.Sprintf("/invitation?owner_id=%s", owner), http.NoBody))
+
+			repo := &stubMemberRepo{}
+			newHandler(repo)(ctx)
+
+			assert.Equal(t, http.StatusNoContent, ctx.Writer.Status())
+			assert.Equal(t, testOwner, repo.user)
+			assert.Equal(t, owner, repo.owner)
+			assert.Equal(t, answer, repo.answered)
+		})
+
+		t.Run("returns 400 on non-valid UUID when "+answer, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("POST", "/invitation", http.NoBody))
+
+			newHandler(&stubMemberRepo{})(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("returns 404 on non existing invitation when "+answer, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("POST", fmt.Sprintf("/invitation?owner_id=%s", uuid.New()), http.NoBody))
+
+			newHandler(&stubMemberRepo{err: repository.ErrMemberNotFound})(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	}
+}
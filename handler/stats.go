@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// statsDefaultDays is the range length used when from is not given.
+	statsDefaultDays = 30
+	// statsMaxDays is the longest range that can be requested.
+	statsMaxDays = 366
+)
+
+type StatsRepo interface {
+	GetStats(ctx context.Context, owner uuid.UUID, from, to time.Time, loc *time.Location) (model.Stats, error)
+}
+
+func NewGetStatsHandler(repo StatsRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		owner, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		loc, err := time.LoadLocation(ctx.DefaultQuery("tz", "UTC"))
+		if err != nil || loc == time.Local {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not load time zone")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		to, err := time.ParseInLocation(time.DateOnly, ctx.DefaultQuery("to", time.Now().In(loc).Format(time.DateOnly)), loc)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse to date")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		from, err := time.ParseInLocation(time.DateOnly, ctx.DefaultQuery("from", to.AddDate(0, 0, 1-statsDefaultDays).Format(time.DateOnly)), loc)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse from date")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		if from.After(to) || !from.AddDate(0, 0, statsMaxDays).After(to) {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		res, err := repo.GetStats(ctx, owner, from, to, loc)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting stats")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, res)
+	}
+}
@@ -0,0 +1,142 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubStatsRepo struct {
+	stats model.Stats
+	err   error
+	owner uuid.UUID
+	from  time.Time
+	to    time.Time
+	loc   *time.Location
+}
+
+func (sr *stubStatsRepo) GetStats(ctx context.Context, owner uuid.UUID, from, to time.Time, loc *time.Location) (model.Stats, error) {
+	sr.owner, sr.from, sr.to, sr.loc = owner, from, to, loc
+	return sr.stats, sr.err
+}
+
+func TestNewGetStatsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/stats?from=2023-03-01&to=2023-03-02&tz=Europe/Paris", http.NoBody))
+
+		expected := model.Stats{
+			Open:              1,
+			Done:              1,
+			CompletionRate:    0.5,
+			AvgTimeToComplete: 60,
+			Days: []model.DayStats{
+				{Date: "2023-03-01", Created: 2, Completed: 1},
+				{Date: "2023-03-02"},
+			},
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubStatsRepo{
+			stats: expected,
+		}
+		hdlr := handler.NewGetStatsHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, r, body)
+		assert.Equal(t, "2023-03-01", repo.from.Format("2006-01-02"))
+		assert.Equal(t, "2023-03-02", repo.to.Format("2006-01-02"))
+		assert.Equal(t, "Europe/Paris", repo.loc.String())
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("defaults to the last 30 days in UTC", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/stats", http.NoBody))
+
+		repo := &stubStatsRepo{}
+		hdlr := handler.NewGetStatsHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, time.UTC, repo.loc)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), repo.to.Format("2006-01-02"))
+		assert.Equal(t, repo.to.AddDate(0, 0, -29), repo.from)
+	})
+
+	for name, query := range map[string]string{
+		"non-valid time zone": "tz=Mars/Olympus",
+		"non-valid from date": "from=2023-13-01",
+		"non-valid to date":   "to=test",
+		"inverted range":      "from=2023-03-02&to=2023-03-01",
+		"too long range":      "from=2020-01-01&to=2023-01-01",
+		"367 days range":      "from=2023-01-01&to=2024-01-02",
+	} {
+		query := query
+		t.Run("returns 400 on "+name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("GET", "/stats?"+query, http.NoBody))
+
+			hdlr := handler.NewGetStatsHandler(&stubStatsRepo{})
+			hdlr(ctx)
+
+			resp := rr.Result()
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Empty(t, body)
+		})
+	}
+
+	t.Run("accepts a 366 days range", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/stats?from=2023-01-01&to=2024-01-01", http.NoBody))
+
+		hdlr := handler.NewGetStatsHandler(&stubStatsRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/stats", http.NoBody))
+
+		hdlr := handler.NewGetStatsHandler(&stubStatsRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, body)
+	})
+}
@@ -4,43 +4,77 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
 	"github.com/iciantoine/todo-go-api/model"
 	"github.com/iciantoine/todo-go-api/repository"
 	"github.com/rs/zerolog/log"
 )
 
 type TodoRepo interface {
-	GetTodos(ctx context.Context) ([]model.Todo, error)
-	GetTodo(ctx context.Context, id uuid.UUID) (model.Todo, error)
-	AddTodo(ctx context.Context, model model.Todo) (model.Todo, error)
+	GetTodos(ctx context.Context, user, owner uuid.UUID, includeArchived bool) ([]model.Todo, error)
+	GetArchivedTodos(ctx context.Context, user, owner uuid.UUID) ([]model.Todo, error)
+	GetTodo(ctx context.Context, user uuid.UUID, id uuid.UUID) (model.Todo, error)
+	AddTodo(ctx context.Context, user, owner uuid.UUID, model model.Todo) (model.Todo, error)
+	UpdateTodo(ctx context.Context, user uuid.UUID, model model.Todo) (model.Todo, error)
 }
 
 func NewGetTodosHandler(repo TodoRepo) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
 		id, exists := ctx.GetQuery("id")
 
 		// ID is given, trying to get the specified todo
 		if exists {
-			getTodo(ctx, repo, id)
+			getTodo(ctx, repo, user, id)
 			return
 		}
 
-		res, err := repo.GetTodos(ctx)
-		if err == nil {
-			ctx.JSON(http.StatusOK, res)
+		owner, ok := listOwner(ctx, user)
+		if !ok {
 			return
 		}
 
-		log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting todos")
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		includeArchived, err := strconv.ParseBool(ctx.DefaultQuery("include_archived", "false"))
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse include_archived")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		res, err := repo.GetTodos(ctx, user, owner, includeArchived)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, res)
+		case errors.Is(err, repository.ErrListNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting todos")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
 	}
 }
 
 func NewPostTodoHandler(repo TodoRepo) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
+			return
+		}
+
+		owner, ok := listOwner(ctx, user)
+		if !ok {
+			return
+		}
+
 		var req model.Todo
 		if err := ctx.ShouldBindJSON(&req); err != nil {
 			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
@@ -48,18 +82,61 @@ func NewPostTodoHandler(repo TodoRepo) gin.HandlerFunc {
 			return
 		}
 
-		res, err := repo.AddTodo(ctx, req)
-		if err != nil {
+		res, err := repo.AddTodo(ctx, user, owner, req)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusCreated, res)
+		case errors.Is(err, repository.ErrListNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, authz.ErrForbidden):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		default:
 			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while adding todo")
 			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
+
+func NewPutTodoHandler(repo TodoRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := owner(ctx)
+		if !ok {
 			return
 		}
 
-		ctx.JSON(http.StatusCreated, res)
+		id, err := uuid.Parse(ctx.Query("id"))
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not parse uuid")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		var req model.Todo
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		req.ID = id
+
+		res, err := repo.UpdateTodo(ctx, user, req)
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, res)
+		case errors.Is(err, repository.ErrTodoNotFound):
+			ctx.AbortWithStatus(http.StatusNotFound)
+		case errors.Is(err, authz.ErrForbidden):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Str("id", id.String()).Err(err).Msg("error while updating todo")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
 	}
 }
 
-func getTodo(ctx *gin.Context, repo TodoRepo, id string) {
+func getTodo(ctx *gin.Context, repo TodoRepo, user uuid.UUID, id string) {
 	if id == "" {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
@@ -72,7 +149,7 @@ func getTodo(ctx *gin.Context, repo TodoRepo, id string) {
 		return
 	}
 
-	res, err := repo.GetTodo(ctx, uuid)
+	res, err := repo.GetTodo(ctx, user, uuid)
 
 	switch {
 	case err == nil:
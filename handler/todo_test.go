@@ -14,27 +14,58 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/authz"
 	"github.com/iciantoine/todo-go-api/handler"
 	"github.com/iciantoine/todo-go-api/model"
 	"github.com/iciantoine/todo-go-api/repository"
 	"github.com/stretchr/testify/assert"
 )
 
+var testOwner = uuid.New()
+
+// authenticate returns the request as sent by testOwner.
+func authenticate(req *http.Request, _ error) *http.Request {
+	return req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: testOwner}))
+}
+
 type stubRepo struct {
-	todo     model.Todo
-	todoList []model.Todo
-	err      error
+	todo            model.Todo
+	todoList        []model.Todo
+	err             error
+	user            uuid.UUID
+	owner           uuid.UUID
+	includeArchived bool
+	updated         model.Todo
+}
+
+func (sr *stubRepo) GetTodos(ctx context.Context, user, owner uuid.UUID, includeArchived bool) ([]model.Todo, error) {
+	sr.user = user
+	sr.owner = owner
+	sr.includeArchived = includeArchived
+	return sr.todoList, sr.err
 }
 
-func (sr *stubRepo) GetTodos(ctx context.Context) ([]model.Todo, error) {
+func (sr *stubRepo) GetArchivedTodos(ctx context.Context, user, owner uuid.UUID) ([]model.Todo, error) {
+	sr.user = user
+	sr.owner = owner
 	return sr.todoList, sr.err
 }
 
-func (sr *stubRepo) GetTodo(ctx context.Context, id uuid.UUID) (model.Todo, error) {
+func (sr *stubRepo) GetTodo(ctx context.Context, user uuid.UUID, id uuid.UUID) (model.Todo, error) {
+	sr.user = user
 	return sr.todo, sr.err
 }
 
-func (sr *stubRepo) AddTodo(ctx context.Context, model model.Todo) (model.Todo, error) {
+func (sr *stubRepo) AddTodo(ctx context.Context, user, owner uuid.UUID, model model.Todo) (model.Todo, error) {
+	sr.user = user
+	sr.owner = owner
+	return sr.todo, sr.err
+}
+
+func (sr *stubRepo) UpdateTodo(ctx context.Context, user uuid.UUID, model model.Todo) (model.Todo, error) {
+	sr.user = user
+	sr.updated = model
 	return sr.todo, sr.err
 }
 
@@ -44,15 +75,16 @@ func TestNewGetTodosHandler(t *testing.T) {
 	t.Run("returns 200 on successful empty list call", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
-		ctx.Request, _ = http.NewRequest("GET", "/todo", http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo", http.NoBody))
 
 		expected := []model.Todo{}
 		r, err := json.Marshal(expected)
 		assert.NoError(t, err)
 
-		hdlr := handler.NewGetTodosHandler(&stubRepo{
+		repo := &stubRepo{
 			todoList: expected,
-		})
+		}
+		hdlr := handler.NewGetTodosHandler(repo)
 		hdlr(ctx)
 
 		resp := rr.Result()
@@ -61,13 +93,25 @@ func TestNewGetTodosHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.owner)
 	})
 
-	t.Run("returns 200 on successful non empty list call", func(t *testing.T) {
+	t.Run("returns 401 on unauthenticated list call", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
 		ctx.Request, _ = http.NewRequest("GET", "/todo", http.NoBody)
 
+		hdlr := handler.NewGetTodosHandler(&stubRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("returns 200 on successful non empty list call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo", http.NoBody))
+
 		expected := []model.Todo{
 			{
 				ID:        uuid.New(),
@@ -98,10 +142,82 @@ func TestNewGetTodosHandler(t *testing.T) {
 		assert.Equal(t, r, body)
 	})
 
+	t.Run("returns 200 on list call including archived todos", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo?include_archived=true", http.NoBody))
+
+		repo := &stubRepo{
+			todoList: []model.Todo{},
+		}
+		hdlr := handler.NewGetTodosHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, repo.includeArchived)
+	})
+
+	t.Run("returns 200 on list call of a shared list", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		owner := uuid.New()
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/todo?owner_id=%s", owner), http.NoBody))
+
+		repo := &stubRepo{
+			todoList: []model.Todo{},
+		}
+		hdlr := handler.NewGetTodosHandler(repo)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, testOwner, repo.user)
+		assert.Equal(t, owner, repo.owner)
+	})
+
+	t.Run("returns 404 on list call of a list not shared with the user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/todo?owner_id=%s", uuid.New()), http.NoBody))
+
+		hdlr := handler.NewGetTodosHandler(&stubRepo{
+			err: repository.ErrListNotFound,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns 400 on list call with non-valid owner_id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo?owner_id=1234", http.NoBody))
+
+		hdlr := handler.NewGetTodosHandler(&stubRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 400 on list call with non-valid include_archived", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo?include_archived=test", http.NoBody))
+
+		hdlr := handler.NewGetTodosHandler(&stubRepo{})
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, body)
+	})
+
 	t.Run("returns 500 on list call with repo error", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
-		ctx.Request, _ = http.NewRequest("GET", "/todo", http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo", http.NoBody))
 
 		hdlr := handler.NewGetTodosHandler(&stubRepo{
 			err: errors.New("test"),
@@ -120,7 +236,7 @@ func TestNewGetTodosHandler(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
 		uuid := uuid.New()
-		ctx.Request, _ = http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.String()), http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.String()), http.NoBody))
 
 		expected := model.Todo{
 			ID:        uuid,
@@ -131,9 +247,10 @@ func TestNewGetTodosHandler(t *testing.T) {
 		r, err := json.Marshal(expected)
 		assert.NoError(t, err)
 
-		hdlr := handler.NewGetTodosHandler(&stubRepo{
+		repo := &stubRepo{
 			todo: expected,
-		})
+		}
+		hdlr := handler.NewGetTodosHandler(repo)
 		hdlr(ctx)
 
 		resp := rr.Result()
@@ -142,13 +259,14 @@ func TestNewGetTodosHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.user)
 	})
 
 	t.Run("returns 404 on non existing todo call", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
 		uuid := uuid.New()
-		ctx.Request, _ = http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.String()), http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.String()), http.NoBody))
 
 		hdlr := handler.NewGetTodosHandler(&stubRepo{
 			err: repository.ErrTodoNotFound,
@@ -166,7 +284,7 @@ func TestNewGetTodosHandler(t *testing.T) {
 	t.Run("returns 500 on todo call with repo error", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
-		ctx.Request, _ = http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.New().String()), http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", fmt.Sprintf("/todo?id=%s", uuid.New().String()), http.NoBody))
 
 		hdlr := handler.NewGetTodosHandler(&stubRepo{
 			err: errors.New("test"),
@@ -184,7 +302,7 @@ func TestNewGetTodosHandler(t *testing.T) {
 	t.Run("returns 400 on todo call with empty ID", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
-		ctx.Request, _ = http.NewRequest("GET", "/todo?id=", http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo?id=", http.NoBody))
 
 		hdlr := handler.NewGetTodosHandler(&stubRepo{})
 		hdlr(ctx)
@@ -200,7 +318,7 @@ func TestNewGetTodosHandler(t *testing.T) {
 	t.Run("returns 400 on todo call with non-valid UUID", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
-		ctx.Request, _ = http.NewRequest("GET", "/todo?id=1234", http.NoBody)
+		ctx.Request = authenticate(http.NewRequest("GET", "/todo?id=1234", http.NoBody))
 
 		hdlr := handler.NewGetTodosHandler(&stubRepo{})
 		hdlr(ctx)
@@ -226,7 +344,7 @@ func TestNewPostTodoHandler(t *testing.T) {
 		})
 		assert.NoError(t, err)
 
-		ctx.Request, _ = http.NewRequest("POST", "/todo", bytes.NewReader(payload))
+		ctx.Request = authenticate(http.NewRequest("POST", "/todo", bytes.NewReader(payload)))
 
 		expected := model.Todo{
 			ID:        uuid.New(),
@@ -237,9 +355,10 @@ func TestNewPostTodoHandler(t *testing.T) {
 		r, err := json.Marshal(expected)
 		assert.NoError(t, err)
 
-		hdlr := handler.NewPostTodoHandler(&stubRepo{
+		repo := &stubRepo{
 			todo: expected,
-		})
+		}
+		hdlr := handler.NewPostTodoHandler(repo)
 		hdlr(ctx)
 
 		resp := rr.Result()
@@ -248,6 +367,18 @@ func TestNewPostTodoHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, rr.Code)
 		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.owner)
+	})
+
+	t.Run("returns 401 on unauthenticated call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request, _ = http.NewRequest("POST", "/todo", bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`)))
+
+		hdlr := handler.NewPostTodoHandler(&stubRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
 	t.Run("returns 400 on wrong payload", func(t *testing.T) {
@@ -258,7 +389,7 @@ func TestNewPostTodoHandler(t *testing.T) {
 		})
 		assert.NoError(t, err)
 
-		ctx.Request, _ = http.NewRequest("POST", "/todo", bytes.NewReader(payload))
+		ctx.Request = authenticate(http.NewRequest("POST", "/todo", bytes.NewReader(payload)))
 
 		hdlr := handler.NewPostTodoHandler(&stubRepo{})
 		hdlr(ctx)
@@ -271,6 +402,34 @@ func TestNewPostTodoHandler(t *testing.T) {
 		assert.Empty(t, body)
 	})
 
+	t.Run("returns 403 when the user can only view the list", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("POST", fmt.Sprintf("/todo?owner_id=%s", uuid.New()),
+			bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`))))
+
+		hdlr := handler.NewPostTodoHandler(&stubRepo{
+			err: authz.ErrForbidden,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("returns 404 when the list is not shared with the user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("POST", fmt.Sprintf("/todo?owner_id=%s", uuid.New()),
+			bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`))))
+
+		hdlr := handler.NewPostTodoHandler(&stubRepo{
+			err: repository.ErrListNotFound,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
 	t.Run("returns 500 on repo error", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(rr)
@@ -280,7 +439,7 @@ func TestNewPostTodoHandler(t *testing.T) {
 		})
 		assert.NoError(t, err)
 
-		ctx.Request, _ = http.NewRequest("POST", "/todo", bytes.NewReader(payload))
+		ctx.Request = authenticate(http.NewRequest("POST", "/todo", bytes.NewReader(payload)))
 
 		hdlr := handler.NewPostTodoHandler(&stubRepo{
 			err: errors.New("test"),
@@ -295,3 +454,89 @@ func TestNewPostTodoHandler(t *testing.T) {
 		assert.Empty(t, body)
 	})
 }
+
+func TestNewPutTodoHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		id := uuid.New()
+		ctx.Request = authenticate(http.NewRequest("PUT", fmt.Sprintf("/todo?id=%s", id),
+			bytes.NewReader([]byte(`{"is_done": true, "message": "Lorem ipsum"}`))))
+
+		expected := model.Todo{
+			ID:        id,
+			CreatedAt: time.Now(),
+			IsDone:    true,
+			Message:   "Lorem ipsum",
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubRepo{
+			todo: expected,
+		}
+		hdlr := handler.NewPutTodoHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, r, body)
+		assert.Equal(t, testOwner, repo.user)
+		assert.Equal(t, id, repo.updated.ID)
+		assert.True(t, repo.updated.IsDone)
+	})
+
+	t.Run("returns 400 on non-valid UUID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("PUT", "/todo?id=1234", bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`))))
+
+		hdlr := handler.NewPutTodoHandler(&stubRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 400 on wrong payload", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request = authenticate(http.NewRequest("PUT", fmt.Sprintf("/todo?id=%s", uuid.New()), bytes.NewReader([]byte(`{}`))))
+
+		hdlr := handler.NewPutTodoHandler(&stubRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	errs := map[error]int{
+		repository.ErrTodoNotFound: http.StatusNotFound,
+		authz.ErrForbidden:         http.StatusForbidden,
+		errors.New("test"):         http.StatusInternalServerError,
+	}
+
+	for err, status := range errs {
+		t.Run(fmt.Sprintf("returns %d on %s error", status, err), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rr)
+			ctx.Request = authenticate(http.NewRequest("PUT", fmt.Sprintf("/todo?id=%s", uuid.New()),
+				bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`))))
+
+			hdlr := handler.NewPutTodoHandler(&stubRepo{
+				err: err,
+			})
+			hdlr(ctx)
+
+			resp := rr.Result()
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, status, rr.Code)
+			assert.Empty(t, body)
+		})
+	}
+}
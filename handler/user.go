@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type UserRepo interface {
+	AddUser(ctx context.Context, model model.User) (model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (model.User, error)
+	AddSession(ctx context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) error
+}
+
+func NewRegisterHandler(repo UserRepo) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req model.Credentials
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while hashing password")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		res, err := repo.AddUser(ctx, model.User{
+			Email:        normalizeEmail(req.Email),
+			PasswordHash: hash,
+		})
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusCreated, res)
+		case errors.Is(err, repository.ErrUserExists):
+			ctx.AbortWithStatus(http.StatusConflict)
+		default:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while adding user")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+}
+
+func NewLoginHandler(repo UserRepo, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req model.Credentials
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			log.Ctx(ctx.Request.Context()).Warn().Err(err).Msg("could not bind request body")
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		user, err := repo.GetUserByEmail(ctx, normalizeEmail(req.Email))
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			auth.DiscardPassword(req.Password)
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		case err != nil:
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while getting user")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ok, err := auth.CheckPassword(user.PasswordHash, req.Password)
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Str("id", user.ID.String()).Err(err).Msg("error while checking password")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		token, err := auth.NewToken()
+		if err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while generating session token")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		res := model.Session{
+			Token:     token,
+			ExpiresAt: time.Now().Add(ttl),
+		}
+
+		if err := repo.AddSession(ctx, user.ID, auth.HashToken(token), res.ExpiresAt); err != nil {
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("error while adding session")
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,215 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+type stubUserRepo struct {
+	user       model.User
+	err        error
+	sessionErr error
+	added      model.User
+	tokenHash  []byte
+}
+
+func (sr *stubUserRepo) AddUser(ctx context.Context, model model.User) (model.User, error) {
+	sr.added = model
+	return sr.user, sr.err
+}
+
+func (sr *stubUserRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	return sr.user, sr.err
+}
+
+func (sr *stubUserRepo) AddSession(ctx context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) error {
+	sr.tokenHash = tokenHash
+	return sr.sessionErr
+}
+
+func TestNewRegisterHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("returns 201 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "John@Example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/register", bytes.NewReader([]byte(payload)))
+
+		expected := model.User{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now(),
+			Email:        "john@example.com",
+			PasswordHash: "secret",
+		}
+		r, err := json.Marshal(expected)
+		assert.NoError(t, err)
+
+		repo := &stubUserRepo{
+			user: expected,
+		}
+		hdlr := handler.NewRegisterHandler(repo)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, r, body)
+		assert.NotContains(t, string(body), "secret")
+
+		assert.Equal(t, "john@example.com", repo.added.Email)
+		ok, err := auth.CheckPassword(repo.added.PasswordHash, "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("returns 400 on wrong payload", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "test", "password": "short"}`
+		ctx.Request, _ = http.NewRequest("POST", "/register", bytes.NewReader([]byte(payload)))
+
+		hdlr := handler.NewRegisterHandler(&stubUserRepo{})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 409 on already registered email", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "john@example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/register", bytes.NewReader([]byte(payload)))
+
+		hdlr := handler.NewRegisterHandler(&stubUserRepo{
+			err: repository.ErrUserExists,
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("returns 500 on repo error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "john@example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/register", bytes.NewReader([]byte(payload)))
+
+		hdlr := handler.NewRegisterHandler(&stubUserRepo{
+			err: errors.New("test"),
+		})
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestNewLoginHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hash, err := auth.HashPassword("password")
+	assert.NoError(t, err)
+
+	user := model.User{
+		ID:           uuid.New(),
+		Email:        "john@example.com",
+		PasswordHash: hash,
+	}
+
+	t.Run("returns 200 on successful call", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "john@example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/login", bytes.NewReader([]byte(payload)))
+
+		repo := &stubUserRepo{
+			user: user,
+		}
+		hdlr := handler.NewLoginHandler(repo, time.Hour)
+		hdlr(ctx)
+
+		resp := rr.Result()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		token := gjson.GetBytes(body, "token").String()
+		assert.NotEmpty(t, token)
+		assert.Equal(t, auth.HashToken(token), repo.tokenHash)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), gjson.GetBytes(body, "expires_at").Time(), time.Minute)
+	})
+
+	t.Run("returns 401 on wrong password", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "john@example.com", "password": "wrong password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/login", bytes.NewReader([]byte(payload)))
+
+		repo := &stubUserRepo{
+			user: user,
+		}
+		hdlr := handler.NewLoginHandler(repo, time.Hour)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Nil(t, repo.tokenHash)
+	})
+
+	t.Run("returns 401 on unknown user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "jane@example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/login", bytes.NewReader([]byte(payload)))
+
+		hdlr := handler.NewLoginHandler(&stubUserRepo{
+			err: repository.ErrUserNotFound,
+		}, time.Hour)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("returns 400 on wrong payload", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		ctx.Request, _ = http.NewRequest("POST", "/login", bytes.NewReader([]byte(`{}`)))
+
+		hdlr := handler.NewLoginHandler(&stubUserRepo{}, time.Hour)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns 500 on session error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rr)
+		payload := `{"email": "john@example.com", "password": "password"}`
+		ctx.Request, _ = http.NewRequest("POST", "/login", bytes.NewReader([]byte(payload)))
+
+		hdlr := handler.NewLoginHandler(&stubUserRepo{
+			user:       user,
+			sessionErr: errors.New("test"),
+		}, time.Hour)
+		hdlr(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -0,0 +1,140 @@
+// Package health tells whether the API is alive and ready to serve requests.
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Statuses of the checks.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Check checks that a dependency of the API is available.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Warning is the error of a check that leaves the API ready, such as one
+// serving in a degraded mode.
+type Warning struct {
+	Err error
+}
+
+// Error implements error.
+func (w Warning) Error() string {
+	return w.Err.Error()
+}
+
+// Unwrap returns the error of the warning.
+func (w Warning) Unwrap() error {
+	return w.Err
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of the checks.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Health runs the readiness checks of the API.
+type Health struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// New instantiates Health, whose readiness checks each have timeout to
+// succeed.
+func New(timeout time.Duration, checks ...Check) *Health {
+	return &Health{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Drain makes the API not ready anymore, so that load balancers stop sending
+// it requests while it shuts down.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Livez answers 200 as long as the API serves requests.
+func (h *Health) Livez() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, Report{Status: StatusOK})
+	}
+}
+
+// Readyz runs the checks concurrently and answers 200 when none fails, 503
+// otherwise or once draining. The report details the result of each check,
+// and warns when one of them warns.
+func (h *Health) Readyz() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res := h.run(ctx.Request.Context())
+		if h.draining.Load() {
+			res.Checks["draining"] = Result{Status: StatusFail, Error: "shutting down"}
+			res.Status = StatusFail
+		}
+
+		if res.Status == StatusFail {
+			log.Ctx(ctx.Request.Context()).Warn().Interface("checks", res.Checks).Msg("not ready")
+			ctx.JSON(http.StatusServiceUnavailable, res)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func (h *Health) run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	res := Report{Status: StatusOK, Checks: make(map[string]Result, len(h.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+
+			var warning Warning
+			r := Result{Status: StatusOK}
+			switch err := c.Check(ctx); {
+			case errors.As(err, &warning):
+				r = Result{Status: StatusWarn, Error: err.Error()}
+			case err != nil:
+				r = Result{Status: StatusFail, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[c.Name] = r
+			if r.Status == StatusFail || res.Status == StatusOK {
+				res.Status = r.Status
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return res
+}
@@ -0,0 +1,99 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ok := health.Check{Name: "ok", Check: func(context.Context) error { return nil }}
+	failing := health.Check{Name: "failing", Check: func(context.Context) error { return errors.New("test") }}
+	warning := health.Check{Name: "warning", Check: func(context.Context) error { return health.Warning{Err: errors.New("test")} }}
+	slow := health.Check{Name: "slow", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	serve := func(h *health.Health, path string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/livez", h.Livez())
+		router.GET("/readyz", h.Readyz())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, http.NoBody))
+		return rr
+	}
+
+	t.Run("alive whatever the checks", func(t *testing.T) {
+		rr := serve(health.New(time.Second, failing), "/livez")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status": "ok"}`, rr.Body.String())
+	})
+
+	t.Run("ready when every check succeeds", func(t *testing.T) {
+		rr := serve(health.New(time.Second, ok), "/readyz")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status": "ok", "checks": {"ok": {"status": "ok"}}}`, rr.Body.String())
+	})
+
+	t.Run("not ready when a check fails", func(t *testing.T) {
+		rr := serve(health.New(time.Second, ok, failing), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{
+			"status": "fail",
+			"checks": {
+				"ok": {"status": "ok"},
+				"failing": {"status": "fail", "error": "test"}
+			}
+		}`, rr.Body.String())
+	})
+
+	t.Run("ready when a check warns", func(t *testing.T) {
+		rr := serve(health.New(time.Second, ok, warning), "/readyz")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"status": "warn",
+			"checks": {
+				"ok": {"status": "ok"},
+				"warning": {"status": "warn", "error": "test"}
+			}
+		}`, rr.Body.String())
+
+		rr = serve(health.New(time.Second, warning, failing), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("not ready when a check times out", func(t *testing.T) {
+		rr := serve(health.New(10*time.Millisecond, slow), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{
+			"status": "fail",
+			"checks": {"slow": {"status": "fail", "error": "context deadline exceeded"}}
+		}`, rr.Body.String())
+	})
+
+	t.Run("not ready once draining", func(t *testing.T) {
+		h := health.New(time.Second, ok)
+		h.Drain()
+
+		rr := serve(h, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{
+			"status": "fail",
+			"checks": {
+				"ok": {"status": "ok"},
+				"draining": {"status": "fail", "error": "shutting down"}
+			}
+		}`, rr.Body.String())
+	})
+}
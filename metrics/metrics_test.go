@@ -0,0 +1,72 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/metrics"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubCounter struct {
+	counts model.TodoCounts
+	err    error
+}
+
+func (sc stubCounter) CountTodos(context.Context) (model.TodoCounts, error) {
+	return sc.counts, sc.err
+}
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(counter metrics.TodoCounter) string {
+		m, err := metrics.New(metrics.NewTodoCollector(counter))
+		require.NoError(t, err)
+
+		router := gin.New()
+		router.Use(m.Middleware())
+		router.GET("/metrics", m.Handler())
+		router.GET("/todo/:id", func(ctx *gin.Context) {
+			ctx.Status(http.StatusNotFound)
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/todo/1", http.NoBody))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/todo/2", http.NoBody))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", http.NoBody))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.String()
+	}
+
+	t.Run("counts requests by route template", func(t *testing.T) {
+		body := serve(stubCounter{})
+
+		assert.Contains(t, body, `http_requests_total{method="GET",route="/todo/:id",status="404"} 2`)
+		assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/todo/:id",status="404"} 2`)
+		assert.Contains(t, body, "go_goroutines")
+	})
+
+	t.Run("counts todos by state", func(t *testing.T) {
+		body := serve(stubCounter{counts: model.TodoCounts{Open: 2, Done: 1, Archived: 3}})
+
+		assert.Contains(t, body, `todo_todos{state="open"} 2`)
+		assert.Contains(t, body, `todo_todos{state="done"} 1`)
+		assert.Contains(t, body, `todo_todos{state="archived"} 3`)
+	})
+
+	t.Run("leaves todos out when they can not be counted", func(t *testing.T) {
+		body := serve(stubCounter{err: errors.New("test")})
+
+		assert.NotContains(t, body, "todo_todos{")
+		assert.Contains(t, body, "http_requests_total")
+	})
+}
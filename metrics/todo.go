@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// countTimeout bounds how long counting todos can delay a scrape.
+const countTimeout = 5 * time.Second
+
+// TodoCounter counts todos by state.
+type TodoCounter interface {
+	CountTodos(ctx context.Context) (model.TodoCounts, error)
+}
+
+// TodoCollector collects the number of todos by state when scraped.
+type TodoCollector struct {
+	counter TodoCounter
+	todos   *prometheus.Desc
+}
+
+// NewTodoCollector instantiates TodoCollector.
+func NewTodoCollector(counter TodoCounter) TodoCollector {
+	return TodoCollector{
+		counter: counter,
+		todos:   prometheus.NewDesc("todo_todos", "Number of todos by state.", []string{"state"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c TodoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.todos
+}
+
+// Collect implements prometheus.Collector.
+func (c TodoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	res, err := c.counter.CountTodos(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not count todos")
+		ch <- prometheus.NewInvalidMetric(c.todos, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.todos, prometheus.GaugeValue, float64(res.Open), "open")
+	ch <- prometheus.MustNewConstMetric(c.todos, prometheus.GaugeValue, float64(res.Done), "done")
+	ch <- prometheus.MustNewConstMetric(c.todos, prometheus.GaugeValue, float64(res.Archived), "archived")
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a credential for non-interactive clients, limited to some scopes.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name" binding:"required"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes" binding:"required,min=1,dive,oneof=todo:read todo:write admin"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	OwnerID    uuid.UUID  `json:"owner_id"`
+	// Key is the API key itself, only known when it is created.
+	Key     string `json:"key,omitempty"`
+	KeyHash []byte `json:"-"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Member is a user a todo list is shared with. The membership is an
+// invitation until the user accepts it.
+type Member struct {
+	OwnerID    uuid.UUID  `json:"owner_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Email      string     `json:"email" binding:"required,email"`
+	Role       string     `json:"role" binding:"required,oneof=editor viewer"`
+	InvitedAt  time.Time  `json:"invited_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// Stats is the todo activity over a period.
+type Stats struct {
+	Open              int64      `json:"open"`
+	Done              int64      `json:"done"`
+	Archived          int64      `json:"archived"`
+	CompletionRate    float64    `json:"completion_rate"`
+	AvgTimeToComplete float64    `json:"avg_time_to_complete_seconds"`
+	Days              []DayStats `json:"days"`
+}
+
+// DayStats is the todo activity of a single day.
+type DayStats struct {
+	Date      string `json:"date"`
+	Created   int64  `json:"created"`
+	Completed int64  `json:"completed"`
+}
+
+// TodoCounts counts todos by state.
+type TodoCounts struct {
+	Open     int64
+	Done     int64
+	Archived int64
+}
@@ -8,8 +8,11 @@ import (
 
 // Todo is the model.
 type Todo struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	IsDone    bool      `json:"is_done"`
-	Message   string    `json:"message" binding:"required"`
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	IsDone      bool       `json:"is_done"`
+	Message     string     `json:"message" binding:"required"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	OwnerID     uuid.UUID  `json:"owner_id"`
 }
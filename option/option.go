@@ -1,34 +1,72 @@
 package option
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
+// Redacted replaces secrets when configuration is logged or printed.
+const Redacted = "REDACTED"
+
 // ConfigureLogging configures Zerolog's log level.
 // See: https://pkg.go.dev/github.com/rs/zerolog#pkg-variables
 func ConfigureLogging(lvl string) error {
+	level, err := ParseLogLevel(lvl)
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
+
+// ParseLogLevel returns Zerolog's log level of the given name, without
+// applying it.
+func ParseLogLevel(lvl string) (zerolog.Level, error) {
 	switch strings.ToLower(lvl) {
 	case "panic":
-		zerolog.SetGlobalLevel(zerolog.PanicLevel)
+		return zerolog.PanicLevel, nil
 	case "fatal":
-		zerolog.SetGlobalLevel(zerolog.FatalLevel)
+		return zerolog.FatalLevel, nil
 	case "error":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+		return zerolog.ErrorLevel, nil
 	case "warn":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+		return zerolog.WarnLevel, nil
 	case "info":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return zerolog.InfoLevel, nil
 	case "debug":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		return zerolog.DebugLevel, nil
 	case "trace":
-		zerolog.SetGlobalLevel(zerolog.TraceLevel)
+		return zerolog.TraceLevel, nil
 	default:
-		return fmt.Errorf("unknown loglevel: %s", lvl)
+		return zerolog.NoLevel, fmt.Errorf("unknown loglevel: %s", lvl)
 	}
-	return nil
+}
+
+// LogFile is a log file rotated when it grows too big.
+type LogFile struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+}
+
+// Shutdown configures the graceful shutdown of the server.
+type Shutdown struct {
+	// Delay is how long the server keeps serving requests once not ready,
+	// so that load balancers stop sending it requests.
+	Delay time.Duration
+	// Timeout is how long requests in flight have to be served.
+	Timeout time.Duration
 }
 
 // Endpoint is an HTTP endpoint.
@@ -37,6 +75,87 @@ type Endpoint struct {
 	Port string
 }
 
+// TLS configures the HTTPS of the server.
+type TLS struct {
+	// CertFile and KeyFile hold the PEM encoded certificate chain and private
+	// key, reloaded when they change. HTTPS is disabled when empty.
+	CertFile string
+	KeyFile  string
+	// MinVersion is the minimum TLS version accepted, such as
+	// tls.VersionTLS12.
+	MinVersion uint16
+	// CipherSuites are the TLS 1.2 cipher suites enabled, Go's defaults when
+	// nil.
+	CipherSuites []uint16
+	// ClientCAFile holds the PEM encoded CA certificates client certificates
+	// are verified against. Client certificates are not requested when
+	// empty.
+	ClientCAFile string
+	// RedirectPort is the port of the plain HTTP listener redirecting to
+	// HTTPS, disabled when empty.
+	RedirectPort string
+}
+
+// Enabled reports whether HTTPS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Archive configures the archiving of completed todos.
+type Archive struct {
+	// After is how long a todo stays completed before being archived. Zero
+	// disables archiving.
+	After    time.Duration
+	Interval time.Duration
+}
+
+// JWT configures the verification of JSON Web Tokens.
+type JWT struct {
+	Issuer      string
+	Audience    string
+	KeyFiles    []string
+	SecretFiles []string
+	JWKSFiles   []string
+}
+
+// Enabled reports whether any verification key is configured.
+func (j JWT) Enabled() bool {
+	return len(j.KeyFiles)+len(j.SecretFiles)+len(j.JWKSFiles) > 0
+}
+
+// OIDC is an OpenID Connect provider issuing access tokens.
+type OIDC struct {
+	IssuerURL   string
+	Audience    string
+	EmailClaim  string
+	GroupsClaim string
+	OrgClaim    string
+}
+
+// Enabled reports whether a provider is configured.
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != ""
+}
+
+// Tenancy is how requests are assigned to an organization.
+type Tenancy struct {
+	// Header names the organization ID of requests.
+	Header string
+	// DefaultOrg is the organization of requests without header, or
+	// uuid.Nil when the header is required.
+	DefaultOrg uuid.UUID
+}
+
+// Tracing configures the export of traces.
+type Tracing struct {
+	// Exporter is "none", "stdout", or "otlp" to send spans to the
+	// OTLP/HTTP collector at Endpoint.
+	Exporter    string
+	Endpoint    string
+	SampleRatio float64
+	ServiceName string
+}
+
 // Postgres is a connection to PostgreSQL.
 type Postgres struct {
 	User string
@@ -48,49 +167,111 @@ type Postgres struct {
 	Cert string
 	Key  string
 	Mode string
+	// Params are the other connection parameters, such as connect_timeout
+	// or application_name.
+	Params map[string]string
 }
 
-// DSN returns the Postgres connection string.
-func (pg Postgres) DSN() string {
-	builder := new(strings.Builder)
+// CheckTLS checks that the CA certificates and client certificate files can
+// be loaded, so that misconfigurations fail at startup with a clear error.
+func (pg Postgres) CheckTLS() error {
+	if pg.CA != "" {
+		data, err := os.ReadFile(pg.CA)
+		if err != nil {
+			return fmt.Errorf("could not read database CA file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM encoded certificate in database CA file: %s", pg.CA)
+		}
+	}
 
-	if pg.User != "" {
-		builder.WriteString(fmt.Sprintf("user=%s", pg.User))
-		builder.WriteByte(' ')
+	if (pg.Cert == "") != (pg.Key == "") {
+		return errors.New("database client certificate and key must be configured together")
 	}
-	if pg.Pass != "" {
-		builder.WriteString(fmt.Sprintf("password=%s", pg.Pass))
-		builder.WriteByte(' ')
+	if pg.Cert == "" {
+		return nil
 	}
-	if pg.Host != "" {
-		builder.WriteString(fmt.Sprintf("host=%s", pg.Host))
-		builder.WriteByte(' ')
+
+	cert, err := tls.LoadX509KeyPair(pg.Cert, pg.Key)
+	if err != nil {
+		return fmt.Errorf("could not load database client certificate: %w", err)
 	}
-	if pg.Port != "" {
-		builder.WriteString(fmt.Sprintf("port=%s", pg.Port))
-		builder.WriteByte(' ')
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("could not parse database client certificate: %w", err)
 	}
-	if pg.Name != "" {
-		builder.WriteString(fmt.Sprintf("dbname=%s", pg.Name))
-		builder.WriteByte(' ')
+	if now := time.Now(); now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("database client certificate %s is only valid from %s to %s", pg.Cert, leaf.NotBefore, leaf.NotAfter)
 	}
-	if pg.CA != "" {
-		builder.WriteString(fmt.Sprintf("sslrootcert=%s", pg.CA))
-		builder.WriteByte(' ')
+
+	return nil
+}
+
+// DSN returns the Postgres connection string, quoting values as needed.
+func (pg Postgres) DSN() string {
+	return pg.dsn(pg.Pass)
+}
+
+// String returns the Postgres connection string with the password redacted,
+// so that it can be logged or printed.
+func (pg Postgres) String() string {
+	if pg.Pass == "" {
+		return pg.dsn("")
 	}
-	if pg.Cert != "" {
-		builder.WriteString(fmt.Sprintf("sslcert=%s", pg.Cert))
-		builder.WriteByte(' ')
+	return pg.dsn(Redacted)
+}
+
+func (pg Postgres) dsn(pass string) string {
+	builder := new(strings.Builder)
+
+	writeParam(builder, "user", pg.User)
+	writeParam(builder, "password", pass)
+	writeParam(builder, "host", pg.Host)
+	writeParam(builder, "port", pg.Port)
+	writeParam(builder, "dbname", pg.Name)
+	writeParam(builder, "sslrootcert", pg.CA)
+	writeParam(builder, "sslcert", pg.Cert)
+	writeParam(builder, "sslkey", pg.Key)
+
+	keys := make([]string, 0, len(pg.Params))
+	for key := range pg.Params {
+		keys = append(keys, key)
 	}
-	if pg.Key != "" {
-		builder.WriteString(fmt.Sprintf("sslkey=%s", pg.Key))
-		builder.WriteByte(' ')
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeParam(builder, key, pg.Params[key])
 	}
+
 	if pg.Mode != "" {
-		builder.WriteString(fmt.Sprintf("sslmode=%s", pg.Mode))
+		builder.WriteString("sslmode=" + quote(pg.Mode))
 	} else {
 		builder.WriteString("sslmode=disable")
 	}
 
 	return builder.String()
 }
+
+// writeParam writes a keyword/value pair of a connection string, followed by
+// a space. Empty values are skipped.
+func writeParam(builder *strings.Builder, key, val string) {
+	if val == "" {
+		return
+	}
+
+	builder.WriteString(key)
+	builder.WriteByte('=')
+	builder.WriteString(quote(val))
+	builder.WriteByte(' ')
+}
+
+// quote quotes a connection string value when it holds spaces, quotes or
+// backslashes, see
+// https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING-KEYWORD-VALUE
+func quote(val string) string {
+	if !strings.ContainsAny(val, " \t\n\r\f\v'\\") {
+		return val
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(val) + "'"
+}
@@ -0,0 +1,104 @@
+// Package problem writes the bodies of error responses as problem details,
+// see RFC 9457.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/requestid"
+	"github.com/rs/zerolog/log"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Details describes an error response.
+type Details struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// New returns the details of a response of the given status, identified by
+// the request ID carried by ctx.
+func New(ctx context.Context, status int) Details {
+	id, _ := requestid.FromContext(ctx)
+
+	return Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		RequestID: id,
+	}
+}
+
+// Middleware gives a problem details body to the error responses left without
+// one, such as those of gin.Context.AbortWithStatus.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer = &writer{ResponseWriter: ctx.Writer, ctx: ctx}
+		ctx.Next()
+	}
+}
+
+// writer writes a problem details body when the header of an error response
+// is written on its own. Bodies written by handlers go through Write and are
+// left untouched.
+type writer struct {
+	gin.ResponseWriter
+	ctx *gin.Context
+}
+
+// WriteHeaderNow implements gin.ResponseWriter.
+func (w *writer) WriteHeaderNow() {
+	if w.Written() || w.Status() < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	body, err := json.Marshal(New(w.ctx.Request.Context(), w.Status()))
+	if err != nil {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// Recovery recovers from panics of the handlers that follow, logs them with
+// their stack trace and answers 500.
+func Recovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				// aborts the response on purpose, see http.ErrAbortHandler
+				panic(err)
+			}
+
+			log.Ctx(ctx.Request.Context()).Error().
+				Interface("panic", err).
+				Bytes("stack", debug.Stack()).
+				Msg("recovered from panic")
+
+			if ctx.Writer.Written() {
+				// too late for a body, the client gets a truncated response
+				ctx.Abort()
+				return
+			}
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}()
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package problem_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/problem"
+	"github.com/iciantoine/todo-go-api/requestid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(requestid.Middleware(), problem.Middleware())
+	router.GET("/abort", func(ctx *gin.Context) {
+		ctx.AbortWithStatus(http.StatusNotFound)
+	})
+	router.GET("/json", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "test"})
+	})
+	router.GET("/ok", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, http.NoBody)
+		req.Header.Set(requestid.Header, "test-id")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("writes problem details of aborted requests", func(t *testing.T) {
+		rr := serve("/abort")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, problem.ContentType, rr.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Not Found",
+			"status": 404,
+			"request_id": "test-id"
+		}`, rr.Body.String())
+	})
+
+	t.Run("keeps bodies written by handlers", func(t *testing.T) {
+		rr := serve("/json")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "test", gjson.Get(rr.Body.String(), "error").String())
+	})
+
+	t.Run("keeps successful responses empty", func(t *testing.T) {
+		rr := serve("/ok")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buf := new(bytes.Buffer)
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	log.Logger = zerolog.New(buf)
+
+	router := gin.New()
+	router.Use(requestid.Middleware(), problem.Middleware(), problem.Recovery())
+	router.GET("/", func(ctx *gin.Context) {
+		panic("test")
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set(requestid.Header, "test-id")
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, problem.ContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "test-id", gjson.Get(rr.Body.String(), "request_id").String())
+
+	assert.Equal(t, "test", gjson.Get(buf.String(), "panic").String())
+	assert.Contains(t, gjson.Get(buf.String(), "stack").String(), "problem_test.go")
+}
@@ -0,0 +1,15 @@
+package ratelimit
+
+import "time"
+
+// SetNow overrides the clock of the store.
+func (s *MemoryStore) SetNow(now func() time.Time) {
+	s.now = now
+}
+
+// Len returns how many buckets the store holds.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
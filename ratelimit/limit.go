@@ -0,0 +1,80 @@
+// Package ratelimit limits the rate of requests with token buckets.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows bursts of Burst requests, refilled continuously over Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit parses a limit formatted as "<burst>/<period>", e.g. "60/1m".
+func ParseLimit(s string) (Limit, error) {
+	burst, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: missing period", s)
+	}
+
+	var res Limit
+	var err error
+	if res.Burst, err = strconv.Atoi(burst); err != nil || res.Burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", s)
+	}
+	if res.Period, err = time.ParseDuration(period); err != nil || res.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+
+	return res, nil
+}
+
+// String implements fmt.Stringer.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// rate returns how many tokens are refilled per second.
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is how many requests are allowed right away.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a request is allowed, zero when allowed.
+	RetryAfter time.Duration
+}
+
+// result returns the result of a bucket left with the given tokens.
+func result(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) / limit.rate() * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / limit.rate() * float64(time.Second))
+	}
+
+	return res
+}
+
+// Store stores token buckets.
+type Store interface {
+	// Take takes a token from the bucket of the key, created full when
+	// missing.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
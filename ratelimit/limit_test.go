@@ -0,0 +1,25 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	t.Run("parses burst and period", func(t *testing.T) {
+		res, err := ratelimit.ParseLimit("60/1m")
+		assert.NoError(t, err)
+		assert.Equal(t, ratelimit.Limit{Burst: 60, Period: time.Minute}, res)
+		assert.Equal(t, "60/1m0s", res.String())
+	})
+
+	for _, s := range []string{"", "60", "test/1m", "0/1m", "-1/1m", "60/test", "60/0s"} {
+		t.Run("error on "+s, func(t *testing.T) {
+			_, err := ratelimit.ParseLimit(s)
+			assert.Error(t, err)
+		})
+	}
+}
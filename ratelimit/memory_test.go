@@ -0,0 +1,67 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	limit := ratelimit.Limit{Burst: 2, Period: 10 * time.Second}
+
+	now := time.Now()
+	SUT := ratelimit.NewMemoryStore()
+	SUT.SetNow(func() time.Time { return now })
+
+	t.Run("allows bursts", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, 1, res.Remaining)
+		assert.Equal(t, 5*time.Second, res.Reset)
+
+		res, err = SUT.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+		assert.Equal(t, 10*time.Second, res.Reset)
+	})
+
+	t.Run("rejects when the bucket is empty", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 5*time.Second, res.RetryAfter)
+	})
+
+	t.Run("keeps buckets apart", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), "other", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		now = now.Add(5 * time.Second)
+
+		res, err := SUT.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+
+		res, err = SUT.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.False(t, res.Allowed)
+	})
+
+	t.Run("forgets full buckets", func(t *testing.T) {
+		now = now.Add(time.Hour)
+
+		_, err := SUT.Take(context.Background(), "new", limit)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, SUT.Len())
+	})
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/rs/zerolog/log"
+)
+
+// Middleware limits the requests of each principal, each API key having its
+// own budget apart from the other credentials of its owner, or of each client
+// IP for unauthenticated requests. Safe methods are limited by read and the
+// others by write, in separate buckets. Responses carry the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, and rejected requests a
+// Retry-After header.
+//
+// Client IPs are only taken from forwarding headers sent by trusted proxies,
+// see gin.Engine.SetTrustedProxies.
+func Middleware(store Store, read, write Limit) gin.HandlerFunc {
+	return limitBy(store, read, write, func(ctx *gin.Context) string {
+		p, ok := auth.PrincipalFrom(ctx.Request.Context())
+		switch {
+		case ok && p.APIKeyID != uuid.Nil:
+			return "apikey:" + p.APIKeyID.String()
+		case ok:
+			return "user:" + p.UserID.String()
+		}
+		return "ip:" + ctx.ClientIP()
+	})
+}
+
+// IPMiddleware limits the requests of each client IP, authenticated or not,
+// sharing the buckets of the unauthenticated requests of Middleware. Used
+// before authenticating, it limits the guessing of credentials.
+func IPMiddleware(store Store, read, write Limit) gin.HandlerFunc {
+	return limitBy(store, read, write, func(ctx *gin.Context) string {
+		return "ip:" + ctx.ClientIP()
+	})
+}
+
+// limitBy limits requests by the key returned by key, in a read and a write
+// budget.
+func limitBy(store Store, read, write Limit, key func(ctx *gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		budget, limit := "write", write
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			budget, limit = "read", read
+		}
+
+		key := budget + ":" + key(ctx)
+
+		res, err := store.Take(ctx.Request.Context(), key, limit)
+		if err != nil {
+			// failing open: an unavailable store must not take the API down
+			log.Ctx(ctx.Request.Context()).Error().Err(err).Msg("could not take rate limit token")
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Header("RateLimit-Reset", seconds(res.Reset))
+
+		if !res.Allowed {
+			ctx.Header("Retry-After", seconds(res.RetryAfter))
+			ctx.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// seconds formats a duration as a number of seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
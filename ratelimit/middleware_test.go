@@ -0,0 +1,152 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/auth"
+	"github.com/iciantoine/todo-go-api/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("test")
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	read := ratelimit.Limit{Burst: 2, Period: time.Minute}
+	write := ratelimit.Limit{Burst: 1, Period: time.Minute}
+
+	newRouter := func(store ratelimit.Store) *gin.Engine {
+		router := gin.New()
+		router.Use(func(ctx *gin.Context) {
+			if user := ctx.GetHeader("X-User"); user != "" {
+				p := auth.Principal{UserID: uuid.MustParse(user)}
+				if key := ctx.GetHeader("X-API-Key"); key != "" {
+					p.APIKeyID = uuid.MustParse(key)
+				}
+				ctx.Request = ctx.Request.WithContext(auth.WithPrincipal(ctx.Request.Context(), p))
+			}
+		}, ratelimit.Middleware(store, read, write))
+		router.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		router.POST("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		return router
+	}
+
+	do := func(router *gin.Engine, method, ip, user string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/", http.NoBody)
+		req.RemoteAddr = ip + ":1234"
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("limits each client IP", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemoryStore())
+
+		rr := do(router, "GET", "192.0.2.1", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rr.Header().Get("RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusOK, do(router, "GET", "192.0.2.1", "").Code)
+
+		rr = do(router, "GET", "192.0.2.1", "")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, do(router, "GET", "192.0.2.2", "").Code)
+	})
+
+	t.Run("limits reads and writes separately", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemoryStore())
+
+		assert.Equal(t, http.StatusOK, do(router, "POST", "192.0.2.1", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(router, "POST", "192.0.2.1", "").Code)
+		assert.Equal(t, http.StatusOK, do(router, "GET", "192.0.2.1", "").Code)
+	})
+
+	t.Run("limits each principal whatever its IP", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemoryStore())
+		user := uuid.New().String()
+
+		assert.Equal(t, http.StatusOK, do(router, "POST", "192.0.2.1", user).Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(router, "POST", "192.0.2.2", user).Code)
+		assert.Equal(t, http.StatusOK, do(router, "POST", "192.0.2.1", uuid.New().String()).Code)
+	})
+
+	t.Run("limits each API key apart from its owner", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemoryStore())
+		user := uuid.New().String()
+
+		withKey := func(key string) int {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", http.NoBody)
+			req.Header.Set("X-User", user)
+			req.Header.Set("X-API-Key", key)
+			router.ServeHTTP(rr, req)
+			return rr.Code
+		}
+
+		key := uuid.New().String()
+		assert.Equal(t, http.StatusOK, withKey(key))
+		assert.Equal(t, http.StatusTooManyRequests, withKey(key))
+		assert.Equal(t, http.StatusOK, withKey(uuid.New().String()))
+		assert.Equal(t, http.StatusOK, do(router, "POST", "192.0.2.1", user).Code)
+	})
+
+	t.Run("limits each client IP whatever its principal", func(t *testing.T) {
+		router := gin.New()
+		router.Use(ratelimit.IPMiddleware(ratelimit.NewMemoryStore(), read, write), func(ctx *gin.Context) {
+			// authentication fails
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		})
+		router.POST("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+		assert.Equal(t, http.StatusUnauthorized, do(router, "POST", "192.0.2.1", uuid.New().String()).Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(router, "POST", "192.0.2.1", uuid.New().String()).Code)
+		assert.Equal(t, http.StatusUnauthorized, do(router, "POST", "192.0.2.2", "").Code)
+	})
+
+	t.Run("ignores forwarding headers of untrusted proxies", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemoryStore())
+		assert.NoError(t, router.SetTrustedProxies(nil))
+
+		for _, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", http.NoBody)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-Forwarded-For", ip)
+			router.ServeHTTP(rr, req)
+
+			if ip == "198.51.100.1" {
+				assert.Equal(t, http.StatusOK, rr.Code)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+			}
+		}
+	})
+
+	t.Run("lets requests through when the store fails", func(t *testing.T) {
+		router := newRouter(failingStore{})
+
+		rr := do(router, "GET", "192.0.2.1", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DBTX is a database connection.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// PostgresStore stores token buckets in the rate_limit table, so that they
+// are shared by the instances of multi instance deployments.
+type PostgresStore struct {
+	db DBTX
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+// NewPostgresStore instantiates PostgresStore.
+func NewPostgresStore(db DBTX) *PostgresStore {
+	return &PostgresStore{
+		db: db,
+	}
+}
+
+// Take implements Store.
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.sweep(ctx)
+
+	const q = `SELECT allowed, remaining FROM rate_limit_take($1, $2, $3)`
+
+	var allowed bool
+	var tokens float64
+	if err := s.db.QueryRowContext(ctx, q, key, float64(limit.Burst), limit.rate()).Scan(&allowed, &tokens); err != nil {
+		return Result{}, err
+	}
+
+	return result(limit, tokens, allowed), nil
+}
+
+// sweep removes the buckets left untouched for a day, they are full by then
+// unless limits have periods longer than a day.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.swept) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.swept = time.Now()
+	s.mu.Unlock()
+
+	const q = `
+		DELETE FROM rate_limit
+		WHERE updated_at < NOW() - INTERVAL '1 day'
+	`
+
+	if _, err := s.db.ExecContext(ctx, q); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("could not remove stale rate limit buckets")
+	}
+}
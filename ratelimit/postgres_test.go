@@ -0,0 +1,55 @@
+//go:build integration
+
+package ratelimit_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/ratelimit"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	SUT := ratelimit.NewPostgresStore(tx)
+	limit := ratelimit.Limit{Burst: 2, Period: time.Hour}
+	key := uuid.NewString()
+
+	t.Run("allows bursts", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), key, limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 1, res.Remaining)
+
+		res, err = SUT.Take(context.Background(), key, limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+
+	t.Run("rejects when the bucket is empty", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), key, limit)
+		assert.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.InDelta(t, 30*time.Minute, res.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("keeps buckets apart", func(t *testing.T) {
+		res, err := SUT.Take(context.Background(), uuid.NewString(), limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+	})
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKeyRepo is the API key repository.
+type APIKeyRepo struct {
+	db DBTX
+}
+
+// NewAPIKeyRepo instantiates APIKeyRepo.
+func NewAPIKeyRepo(db DBTX) APIKeyRepo {
+	return APIKeyRepo{
+		db: db,
+	}
+}
+
+// GetAPIKeys gets the non revoked API keys of the owner ordered by creation date from most newest to oldest.
+func (repo APIKeyRepo) GetAPIKeys(ctx context.Context, owner uuid.UUID) ([]model.APIKey, error) {
+	defer observe("APIKeyRepo", "GetAPIKeys")()
+
+	const q = `
+		SELECT id, name, prefix, scopes, created_at, expires_at, last_used_at, owner_id
+		FROM api_key
+		WHERE owner_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return query(ctx, repo.db, scanAPIKey, q, owner)
+}
+
+// AddAPIKey adds an API key model for the owner.
+func (repo APIKeyRepo) AddAPIKey(ctx context.Context, owner uuid.UUID, model model.APIKey) (model.APIKey, error) {
+	defer observe("APIKeyRepo", "AddAPIKey")()
+
+	model.ID = uuid.New()
+	model.OwnerID = owner
+	model.CreatedAt = time.Now()
+	model.LastUsedAt = nil
+
+	const q = `
+		INSERT INTO api_key (id, owner_id, name, prefix, key_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := exec(ctx, repo.db, q, model.ID, model.OwnerID, model.Name, model.Prefix, model.KeyHash,
+		strings.Join(model.Scopes, " "), model.CreatedAt, model.ExpiresAt)
+
+	return model, err
+}
+
+// RevokeAPIKey revokes one API key of the owner by its ID or throws an error.
+func (repo APIKeyRepo) RevokeAPIKey(ctx context.Context, owner uuid.UUID, id uuid.UUID) error {
+	defer observe("APIKeyRepo", "RevokeAPIKey")()
+
+	const q = `
+		UPDATE api_key
+		SET revoked_at = NOW()
+		WHERE owner_id = $1 AND id = $2 AND revoked_at IS NULL
+	`
+
+	res, err := exec(ctx, repo.db, q, owner, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = ErrAPIKeyNotFound
+	}
+
+	return err
+}
+
+// UseAPIKey retrieves a valid API key by its hash and records it has been
+// used, or throws an error.
+func (repo APIKeyRepo) UseAPIKey(ctx context.Context, keyHash []byte) (model.APIKey, error) {
+	defer observe("APIKeyRepo", "UseAPIKey")()
+
+	const q = `
+		UPDATE api_key
+		SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id, name, prefix, scopes, created_at, expires_at, last_used_at, owner_id
+	`
+
+	res, err := queryRow(ctx, repo.db, scanAPIKey, q, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, ErrAPIKeyNotFound
+	}
+
+	return res, err
+}
+
+func scanAPIKey(row scanner) (model.APIKey, error) {
+	var val model.APIKey
+	var scopes string
+	err := row.Scan(&val.ID, &val.Name, &val.Prefix, &scopes, &val.CreatedAt, &val.ExpiresAt, &val.LastUsedAt, &val.OwnerID)
+	val.Scopes = strings.Fields(scopes)
+	return val, err
+}
@@ -0,0 +1,134 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAPIKey(t *testing.T) {
+	t.Run("it should add an API key and return it", func(t *testing.T) {
+		SUT, teardown := setupAPIKey(t)
+		defer teardown()
+
+		res, err := SUT.AddAPIKey(context.Background(), john, model.APIKey{
+			Name:    "CI",
+			Prefix:  "tdk_12345678",
+			Scopes:  []string{"todo:read", "todo:write"},
+			KeyHash: []byte("hash"),
+		})
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, uuid.Nil, res.ID)
+		assert.Equal(t, john, res.OwnerID)
+		assert.NotZero(t, res.CreatedAt)
+
+		keys, err := SUT.GetAPIKeys(context.Background(), john)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Equal(t, []string{"todo:read", "todo:write"}, keys[0].Scopes)
+		assert.Equal(t, "tdk_12345678", keys[0].Prefix)
+		assert.Nil(t, keys[0].LastUsedAt)
+
+		keys, err = SUT.GetAPIKeys(context.Background(), jane)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
+
+func TestUseAPIKey(t *testing.T) {
+	t.Run("it should return the API key and record its use", func(t *testing.T) {
+		SUT, teardown := setupAPIKey(t)
+		defer teardown()
+
+		added, err := SUT.AddAPIKey(context.Background(), john, model.APIKey{
+			Name:    "CI",
+			Scopes:  []string{"todo:read"},
+			KeyHash: []byte("hash"),
+		})
+		assert.NoError(t, err)
+
+		res, err := SUT.UseAPIKey(context.Background(), []byte("hash"))
+		assert.NoError(t, err)
+		assert.Equal(t, added.ID, res.ID)
+		assert.Equal(t, john, res.OwnerID)
+		assert.NotNil(t, res.LastUsedAt)
+	})
+
+	t.Run("it should return a not found error on expired API key", func(t *testing.T) {
+		SUT, teardown := setupAPIKey(t)
+		defer teardown()
+
+		expiresAt := time.Now().Add(-time.Hour)
+		_, err := SUT.AddAPIKey(context.Background(), john, model.APIKey{
+			Name:      "CI",
+			Scopes:    []string{"todo:read"},
+			KeyHash:   []byte("hash"),
+			ExpiresAt: &expiresAt,
+		})
+		assert.NoError(t, err)
+
+		_, err = SUT.UseAPIKey(context.Background(), []byte("hash"))
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+	})
+
+	t.Run("it should return a not found error on revoked API key", func(t *testing.T) {
+		SUT, teardown := setupAPIKey(t)
+		defer teardown()
+
+		added, err := SUT.AddAPIKey(context.Background(), john, model.APIKey{
+			Name:    "CI",
+			Scopes:  []string{"todo:read"},
+			KeyHash: []byte("hash"),
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, SUT.RevokeAPIKey(context.Background(), john, added.ID))
+
+		_, err = SUT.UseAPIKey(context.Background(), []byte("hash"))
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+
+		keys, err := SUT.GetAPIKeys(context.Background(), john)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	t.Run("it should return a not found error for API keys of other owners", func(t *testing.T) {
+		SUT, teardown := setupAPIKey(t)
+		defer teardown()
+
+		added, err := SUT.AddAPIKey(context.Background(), john, model.APIKey{
+			Name:    "CI",
+			Scopes:  []string{"todo:read"},
+			KeyHash: []byte("hash"),
+		})
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, SUT.RevokeAPIKey(context.Background(), jane, added.ID), repository.ErrAPIKeyNotFound)
+	})
+}
+
+func setupAPIKey(t *testing.T) (repository.APIKeyRepo, func()) {
+	db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	assert.NoError(t, err)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	assert.NoError(t, err)
+	setOrg(t, tx, defaultOrg)
+
+	SUT := repository.NewAPIKeyRepo(tx)
+
+	return SUT, func() {
+		assert.NoError(t, tx.Rollback())
+		assert.NoError(t, db.Close())
+	}
+}
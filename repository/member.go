@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+var ErrMemberNotFound = errors.New("member not found")
+
+// MemberRepo is the repository of the users todo lists are shared with.
+type MemberRepo struct {
+	db DBTX
+}
+
+// NewMemberRepo instantiates MemberRepo.
+func NewMemberRepo(db DBTX) MemberRepo {
+	return MemberRepo{
+		db: db,
+	}
+}
+
+// GetMembers gets the members of the list of the owner, invitations included,
+// ordered by invitation date from oldest to newest.
+func (repo MemberRepo) GetMembers(ctx context.Context, user, owner uuid.UUID) ([]model.Member, error) {
+	defer observe("MemberRepo", "GetMembers")()
+
+	const q = `
+		SELECT m.owner_id, m.user_id, u.email, m.role, m.invited_at, m.accepted_at
+		FROM list_member m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.owner_id = $1
+		ORDER BY m.invited_at
+	`
+
+	var res []model.Member
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionRead); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = list(scanMember)(repo.db.QueryContext(ctx, q, owner))
+		return err
+	})
+
+	return res, err
+}
+
+// InviteMember invites the registered user of the model email to the list of
+// the owner with the model role. Inviting a member again changes their role.
+// Users can not invite themselves.
+func (repo MemberRepo) InviteMember(ctx context.Context, user, owner uuid.UUID, model model.Member) (model.Member, error) {
+	defer observe("MemberRepo", "InviteMember")()
+
+	const q = `
+		WITH invited AS (
+			SELECT id, email
+			FROM users
+			WHERE email = $2 AND id <> $1
+		), member AS (
+			INSERT INTO list_member (owner_id, user_id, role, invited_at)
+			SELECT $1, id, $3, NOW()
+			FROM invited
+			ON CONFLICT (owner_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			RETURNING owner_id, user_id, role, invited_at, accepted_at
+		)
+		SELECT m.owner_id, m.user_id, i.email, m.role, m.invited_at, m.accepted_at
+		FROM member m
+		JOIN invited i ON i.id = m.user_id
+	`
+
+	res := model
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionShare); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = scanMember(repo.db.QueryRowContext(ctx, q, owner, model.Email, model.Role))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+
+		return err
+	})
+
+	return res, err
+}
+
+// RemoveMember removes a member, or cancels an invitation, of the list of the
+// owner or throws an error.
+func (repo MemberRepo) RemoveMember(ctx context.Context, user, owner, member uuid.UUID) error {
+	defer observe("MemberRepo", "RemoveMember")()
+
+	const q = `
+		DELETE FROM list_member
+		WHERE owner_id = $1 AND user_id = $2
+	`
+
+	return inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionShare); err != nil {
+			return err
+		}
+
+		return affectMember(repo.db.ExecContext(ctx, q, owner, member))
+	})
+}
+
+// GetInvitations gets the lists the user is invited to or member of, ordered
+// by invitation date from most newest to oldest.
+func (repo MemberRepo) GetInvitations(ctx context.Context, user uuid.UUID) ([]model.Member, error) {
+	defer observe("MemberRepo", "GetInvitations")()
+
+	const q = `
+		SELECT m.owner_id, m.user_id, u.email, m.role, m.invited_at, m.accepted_at
+		FROM list_member m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.user_id = $1
+		ORDER BY m.invited_at DESC
+	`
+
+	return query(ctx, repo.db, scanMember, q, user)
+}
+
+// AcceptInvitation accepts the invitation of the user to the list of the
+// owner or throws an error.
+func (repo MemberRepo) AcceptInvitation(ctx context.Context, user, owner uuid.UUID) error {
+	defer observe("MemberRepo", "AcceptInvitation")()
+
+	const q = `
+		UPDATE list_member
+		SET accepted_at = NOW()
+		WHERE owner_id = $1 AND user_id = $2 AND accepted_at IS NULL
+	`
+
+	return affectMember(exec(ctx, repo.db, q, owner, user))
+}
+
+// LeaveList declines the invitation of the user to the list of the owner, or
+// removes the user from its members, or throws an error.
+func (repo MemberRepo) LeaveList(ctx context.Context, user, owner uuid.UUID) error {
+	defer observe("MemberRepo", "LeaveList")()
+
+	const q = `
+		DELETE FROM list_member
+		WHERE owner_id = $1 AND user_id = $2
+	`
+
+	return affectMember(exec(ctx, repo.db, q, owner, user))
+}
+
+// affectMember checks a statement affected a member.
+func affectMember(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = ErrMemberNotFound
+	}
+
+	return err
+}
+
+func scanMember(row scanner) (model.Member, error) {
+	var val model.Member
+	err := row.Scan(&val.OwnerID, &val.UserID, &val.Email, &val.Role, &val.InvitedAt, &val.AcceptedAt)
+	return val, err
+}
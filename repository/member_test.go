@@ -0,0 +1,167 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/authz"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMembers(t *testing.T) {
+	t.Run("it should return the members of the list", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		res, err := SUT.GetMembers(context.Background(), john, john)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, jane, res[0].UserID)
+		assert.Equal(t, "jane@example.com", res[0].Email)
+		assert.Equal(t, "viewer", res[0].Role)
+		assert.NotNil(t, res[0].AcceptedAt)
+	})
+
+	t.Run("it should return the members to members of the list", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		res, err := SUT.GetMembers(context.Background(), jane, john)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+	})
+
+	t.Run("it should return a not found error for lists not shared with the user", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		_, err := SUT.GetMembers(context.Background(), john, jane)
+		assert.ErrorIs(t, err, repository.ErrListNotFound)
+	})
+}
+
+func TestInviteMember(t *testing.T) {
+	t.Run("it should invite a user and return the invitation", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		res, err := SUT.InviteMember(context.Background(), jane, jane, model.Member{Email: "john@example.com", Role: "editor"})
+		assert.NoError(t, err)
+		assert.Equal(t, jane, res.OwnerID)
+		assert.Equal(t, john, res.UserID)
+		assert.Equal(t, "editor", res.Role)
+		assert.NotZero(t, res.InvitedAt)
+		assert.Nil(t, res.AcceptedAt)
+	})
+
+	t.Run("it should change the role of members invited again", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		res, err := SUT.InviteMember(context.Background(), john, john, model.Member{Email: "jane@example.com", Role: "editor"})
+		assert.NoError(t, err)
+		assert.Equal(t, "editor", res.Role)
+		assert.NotNil(t, res.AcceptedAt)
+	})
+
+	t.Run("it should return a not found error for unknown users", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		_, err := SUT.InviteMember(context.Background(), john, john, model.Member{Email: "nobody@example.com", Role: "viewer"})
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+		_, err = SUT.InviteMember(context.Background(), john, john, model.Member{Email: "john@example.com", Role: "viewer"})
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("it should return a forbidden error for members of the list", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		_, err := SUT.InviteMember(context.Background(), jane, john, model.Member{Email: "jane@example.com", Role: "editor"})
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+}
+
+func TestRemoveMember(t *testing.T) {
+	t.Run("it should remove a member", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		assert.NoError(t, SUT.RemoveMember(context.Background(), john, john, jane))
+
+		_, err := SUT.GetMembers(context.Background(), jane, john)
+		assert.ErrorIs(t, err, repository.ErrListNotFound)
+	})
+
+	t.Run("it should return a not found error for unknown members", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		err := SUT.RemoveMember(context.Background(), jane, jane, john)
+		assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+	})
+}
+
+func TestInvitations(t *testing.T) {
+	t.Run("it should return the invitations of the user", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		res, err := SUT.GetInvitations(context.Background(), jane)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, john, res[0].OwnerID)
+	})
+
+	t.Run("it should accept an invitation", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		_, err := SUT.InviteMember(context.Background(), jane, jane, model.Member{Email: "john@example.com", Role: "viewer"})
+		assert.NoError(t, err)
+
+		_, err = SUT.GetMembers(context.Background(), john, jane)
+		assert.ErrorIs(t, err, repository.ErrListNotFound)
+
+		assert.NoError(t, SUT.AcceptInvitation(context.Background(), john, jane))
+
+		_, err = SUT.GetMembers(context.Background(), john, jane)
+		assert.NoError(t, err)
+
+		err = SUT.AcceptInvitation(context.Background(), john, jane)
+		assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+	})
+
+	t.Run("it should leave a list", func(t *testing.T) {
+		SUT, teardown := setupMember(t)
+		defer teardown()
+
+		assert.NoError(t, SUT.LeaveList(context.Background(), jane, john))
+
+		err := SUT.LeaveList(context.Background(), jane, john)
+		assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+	})
+}
+
+func setupMember(t *testing.T) (repository.MemberRepo, func()) {
+	db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	assert.NoError(t, err)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	assert.NoError(t, err)
+	setOrg(t, tx, defaultOrg)
+
+	SUT := repository.NewMemberRepo(tx)
+
+	return SUT, func() {
+		assert.NoError(t, tx.Rollback())
+		assert.NoError(t, db.Close())
+	}
+}
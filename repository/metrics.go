@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueryDuration observes how long repository methods take to query the
+// database, by repository and method.
+var QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "todo_repository_query_duration_seconds",
+	Help:    "Duration of repository queries.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"repository", "method"})
+
+// observe starts timing a repository method, the returned function observes
+// its duration.
+func observe(repo, method string) func() {
+	start := time.Now()
+	return func() {
+		QueryDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+	}
+}
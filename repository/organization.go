@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationRepo is the organization repository. Organizations are not
+// restricted by row level security, they are the tenants it isolates.
+type OrganizationRepo struct {
+	db DBTX
+}
+
+// NewOrganizationRepo instantiates OrganizationRepo.
+func NewOrganizationRepo(db DBTX) OrganizationRepo {
+	return OrganizationRepo{
+		db: db,
+	}
+}
+
+// GetOrganizationIDs gets the IDs of every organization.
+func (repo OrganizationRepo) GetOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	defer observe("OrganizationRepo", "GetOrganizationIDs")()
+
+	const q = `
+		SELECT id
+		FROM organization
+		ORDER BY created_at
+	`
+
+	return list(scanID)(repo.db.QueryContext(ctx, q))
+}
+
+func scanID(row scanner) (uuid.UUID, error) {
+	var val uuid.UUID
+	err := row.Scan(&val)
+	return val, err
+}
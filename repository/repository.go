@@ -28,6 +28,59 @@ type Tx interface {
 	Rollback() error
 }
 
+// transactor is a database connection running functions in transactions, the
+// queries made with the context given to them running in it, such as
+// database.OrgDB.
+type transactor interface {
+	Tx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// inTx runs fn in a transaction of db when it runs transactions, and directly
+// otherwise, such as when db is a transaction already.
+func inTx(ctx context.Context, db DBTX, fn func(ctx context.Context) error) error {
+	if t, ok := db.(transactor); ok {
+		return t.Tx(ctx, fn)
+	}
+
+	return fn(ctx)
+}
+
+// exec executes a statement in a transaction of db, see inTx.
+func exec(ctx context.Context, db DBTX, q string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := inTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		res, err = db.ExecContext(ctx, q, args...)
+		return err
+	})
+
+	return res, err
+}
+
+// queryRow selects one entity in a transaction of db, see inTx.
+func queryRow[T any](ctx context.Context, db DBTX, scan func(scanner) (T, error), q string, args ...any) (T, error) {
+	var res T
+	err := inTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		res, err = scan(db.QueryRowContext(ctx, q, args...))
+		return err
+	})
+
+	return res, err
+}
+
+// query selects a list of entities in a transaction of db, see inTx and list.
+func query[T any](ctx context.Context, db DBTX, scan func(scanner) (T, error), q string, args ...any) ([]T, error) {
+	var res []T
+	err := inTx(ctx, db, func(ctx context.Context) error {
+		var err error
+		res, err = list(scan)(db.QueryContext(ctx, q, args...))
+		return err
+	})
+
+	return res, err
+}
+
 // Used to make scanning consistent.
 type scanner interface {
 	Scan(args ...any) error
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+// StatsRepo is the todo statistics repository.
+type StatsRepo struct {
+	db DBTX
+}
+
+// NewStatsRepo instantiates StatsRepo.
+func NewStatsRepo(db DBTX) StatsRepo {
+	return StatsRepo{
+		db: db,
+	}
+}
+
+// GetStats aggregates the activity of the owner's todos created between the
+// from and to days, both included, as seen from the given location.
+func (repo StatsRepo) GetStats(ctx context.Context, owner uuid.UUID, from, to time.Time, loc *time.Location) (model.Stats, error) {
+	defer observe("StatsRepo", "GetStats")()
+
+	const q = `
+		SELECT
+			COUNT(*) FILTER (WHERE NOT is_done),
+			COUNT(*) FILTER (WHERE is_done AND archived_at IS NULL),
+			COUNT(*) FILTER (WHERE archived_at IS NOT NULL),
+			COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)) FILTER (WHERE is_done), 0)::FLOAT8
+		FROM todo
+		WHERE owner_id = $4
+			AND created_at >= $1::DATE::TIMESTAMP AT TIME ZONE $3
+			AND created_at < ($2::DATE + 1)::TIMESTAMP AT TIME ZONE $3
+	`
+
+	var res model.Stats
+	args := []any{from.Format(time.DateOnly), to.Format(time.DateOnly), loc.String(), owner}
+
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		err := repo.db.QueryRowContext(ctx, q, args...).Scan(&res.Open, &res.Done, &res.Archived, &res.AvgTimeToComplete)
+		if err != nil {
+			return err
+		}
+
+		res.Days, err = repo.getDays(ctx, args...)
+		return err
+	})
+	if err != nil {
+		return res, err
+	}
+
+	if total := res.Open + res.Done + res.Archived; total > 0 {
+		res.CompletionRate = float64(res.Done+res.Archived) / float64(total)
+	}
+
+	return res, nil
+}
+
+// CountTodos counts the todos of every owner by state.
+func (repo StatsRepo) CountTodos(ctx context.Context) (model.TodoCounts, error) {
+	defer observe("StatsRepo", "CountTodos")()
+
+	const q = `
+		SELECT
+			COUNT(*) FILTER (WHERE NOT is_done),
+			COUNT(*) FILTER (WHERE is_done AND archived_at IS NULL),
+			COUNT(*) FILTER (WHERE archived_at IS NOT NULL)
+		FROM todo
+	`
+
+	var res model.TodoCounts
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		return repo.db.QueryRowContext(ctx, q).Scan(&res.Open, &res.Done, &res.Archived)
+	})
+
+	return res, err
+}
+
+// getDays builds the per-day histogram of created and completed todos.
+func (repo StatsRepo) getDays(ctx context.Context, args ...any) ([]model.DayStats, error) {
+	const q = `
+		WITH days AS (
+			SELECT day::DATE AS day
+			FROM generate_series($1::DATE, $2::DATE, INTERVAL '1 day') AS day
+		), created AS (
+			SELECT (created_at AT TIME ZONE $3)::DATE AS day, COUNT(*) AS n
+			FROM todo
+			WHERE owner_id = $4
+				AND created_at >= $1::DATE::TIMESTAMP AT TIME ZONE $3
+				AND created_at < ($2::DATE + 1)::TIMESTAMP AT TIME ZONE $3
+			GROUP BY 1
+		), completed AS (
+			SELECT (completed_at AT TIME ZONE $3)::DATE AS day, COUNT(*) AS n
+			FROM todo
+			WHERE owner_id = $4
+				AND completed_at >= $1::DATE::TIMESTAMP AT TIME ZONE $3
+				AND completed_at < ($2::DATE + 1)::TIMESTAMP AT TIME ZONE $3
+			GROUP BY 1
+		)
+		SELECT TO_CHAR(days.day, 'YYYY-MM-DD'), COALESCE(created.n, 0), COALESCE(completed.n, 0)
+		FROM days
+		LEFT JOIN created USING (day)
+		LEFT JOIN completed USING (day)
+		ORDER BY days.day
+	`
+
+	return list(scanDay)(repo.db.QueryContext(ctx, q, args...))
+}
+
+func scanDay(row scanner) (model.DayStats, error) {
+	var val model.DayStats
+	err := row.Scan(&val.Date, &val.Created, &val.Completed)
+	return val, err
+}
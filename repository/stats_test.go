@@ -0,0 +1,96 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStats(t *testing.T) {
+	t.Run("it should aggregate the todos created in the range", func(t *testing.T) {
+		SUT, teardown := setupStats(t)
+		defer teardown()
+
+		from := time.Date(2023, 3, 5, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2023, 3, 7, 0, 0, 0, 0, time.UTC)
+
+		res, err := SUT.GetStats(context.Background(), john, from, to, time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, int64(1), res.Open)
+		assert.Equal(t, int64(1), res.Done)
+		assert.Zero(t, res.Archived)
+		assert.Equal(t, 0.5, res.CompletionRate)
+		assert.Zero(t, res.AvgTimeToComplete)
+		assert.Equal(t, []model.DayStats{
+			{Date: "2023-03-05"},
+			{Date: "2023-03-06", Created: 2, Completed: 1},
+			{Date: "2023-03-07"},
+		}, res.Days)
+	})
+
+	t.Run("it should bucket days in the given time zone", func(t *testing.T) {
+		SUT, teardown := setupStats(t)
+		defer teardown()
+
+		loc, err := time.LoadLocation("Pacific/Kiritimati") // UTC+14
+		assert.NoError(t, err)
+
+		from := time.Date(2023, 3, 7, 0, 0, 0, 0, loc)
+
+		res, err := SUT.GetStats(context.Background(), john, from, from, loc)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []model.DayStats{
+			{Date: "2023-03-07", Created: 2, Completed: 1},
+		}, res.Days)
+	})
+
+	t.Run("it should return empty stats on an empty range", func(t *testing.T) {
+		SUT, teardown := setupStats(t)
+		defer teardown()
+
+		from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		res, err := SUT.GetStats(context.Background(), john, from, from, time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, model.Stats{
+			Days: []model.DayStats{{Date: "2022-01-01"}},
+		}, res)
+	})
+}
+
+func TestCountTodos(t *testing.T) {
+	t.Run("it should count the todos of the organization by state", func(t *testing.T) {
+		SUT, teardown := setupStats(t)
+		defer teardown()
+
+		res, err := SUT.CountTodos(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, model.TodoCounts{Open: 2, Done: 1, Archived: 1}, res)
+	})
+}
+
+func setupStats(t *testing.T) (repository.StatsRepo, func()) {
+	db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	assert.NoError(t, err)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	assert.NoError(t, err)
+	setOrg(t, tx, defaultOrg)
+
+	SUT := repository.NewStatsRepo(tx)
+
+	return SUT, func() {
+		assert.NoError(t, tx.Rollback())
+		assert.NoError(t, db.Close())
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
 	"github.com/iciantoine/todo-go-api/model"
 )
 
-var ErrTodoNotFound = errors.New("todo not found")
+var (
+	ErrTodoNotFound = errors.New("todo not found")
+	ErrListNotFound = errors.New("todo list not found")
+)
 
-// TodoRepo is the todo repository.
+// TodoRepo is the todo repository. Every query is authorized against the role
+// of the user on the todo list it reads or writes, in the same transaction.
 type TodoRepo struct {
 	db DBTX
 }
@@ -24,21 +29,167 @@ func NewTodoRepo(db DBTX) TodoRepo {
 	}
 }
 
-// GetTodos gets all the todos ordered by creation date from most newest to oldest.
-func (repo TodoRepo) GetTodos(ctx context.Context) ([]model.Todo, error) {
+// GetTodos gets all the todos of the list of the owner ordered by creation date from most newest to oldest.
+// Archived todos are left out unless includeArchived is set.
+func (repo TodoRepo) GetTodos(ctx context.Context, user, owner uuid.UUID, includeArchived bool) ([]model.Todo, error) {
+	defer observe("TodoRepo", "GetTodos")()
+
 	const q = `
-		SELECT id, created_at, is_done, message
+		SELECT id, created_at, is_done, message, completed_at, archived_at, owner_id
 		FROM todo
+		WHERE owner_id = $1 AND ($2 OR archived_at IS NULL)
 		ORDER BY created_at DESC
 	`
 
-	return list(scan)(repo.db.QueryContext(ctx, q))
+	var res []model.Todo
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionRead); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = list(scan)(repo.db.QueryContext(ctx, q, owner, includeArchived))
+		return err
+	})
+
+	return res, err
+}
+
+// GetArchivedTodos gets the archived todos of the list of the owner ordered by archiving date from most newest to oldest.
+func (repo TodoRepo) GetArchivedTodos(ctx context.Context, user, owner uuid.UUID) ([]model.Todo, error) {
+	defer observe("TodoRepo", "GetArchivedTodos")()
+
+	const q = `
+		SELECT id, created_at, is_done, message, completed_at, archived_at, owner_id
+		FROM todo
+		WHERE owner_id = $1 AND archived_at IS NOT NULL
+		ORDER BY archived_at DESC
+	`
+
+	var res []model.Todo
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionRead); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = list(scan)(repo.db.QueryContext(ctx, q, owner))
+		return err
+	})
+
+	return res, err
+}
+
+// GetTodo retrives one todo the user can read by its ID or throws an error.
+func (repo TodoRepo) GetTodo(ctx context.Context, user uuid.UUID, id uuid.UUID) (model.Todo, error) {
+	defer observe("TodoRepo", "GetTodo")()
+
+	var res model.Todo
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		var err error
+		if res, err = repo.getTodo(ctx, id); err != nil {
+			return err
+		}
+
+		return authorizeTodo(ctx, repo.db, user, res.OwnerID, authz.ActionRead)
+	})
+	if err != nil {
+		return model.Todo{}, err
+	}
+
+	return res, nil
+}
+
+// AddTodo adds a todo model to the list of the owner.
+func (repo TodoRepo) AddTodo(ctx context.Context, user, owner uuid.UUID, model model.Todo) (model.Todo, error) {
+	defer observe("TodoRepo", "AddTodo")()
+
+	model.ID = uuid.New()
+	model.OwnerID = owner
+	model.CreatedAt = time.Now()
+	model.CompletedAt = nil
+	model.ArchivedAt = nil
+	if model.IsDone {
+		model.CompletedAt = &model.CreatedAt
+	}
+
+	const q = `
+		INSERT INTO todo (id, created_at, is_done, message, completed_at, owner_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		if err := authorizeList(ctx, repo.db, user, owner, authz.ActionWrite); err != nil {
+			return err
+		}
+
+		_, err := repo.db.ExecContext(ctx, q, model.ID, model.CreatedAt, model.IsDone, model.Message, model.CompletedAt, model.OwnerID)
+		return err
+	})
+
+	return model, err
+}
+
+// UpdateTodo updates the message and status of a todo the user can write by
+// its ID or throws an error. Reopened todos are no longer completed nor
+// archived.
+func (repo TodoRepo) UpdateTodo(ctx context.Context, user uuid.UUID, model model.Todo) (model.Todo, error) {
+	defer observe("TodoRepo", "UpdateTodo")()
+
+	const q = `
+		UPDATE todo
+		SET message = $2, is_done = $3,
+			completed_at = CASE WHEN $3 THEN COALESCE(completed_at, NOW()) END,
+			archived_at = CASE WHEN $3 THEN archived_at END
+		WHERE id = $1
+		RETURNING id, created_at, is_done, message, completed_at, archived_at, owner_id
+	`
+
+	res := model
+	err := inTx(ctx, repo.db, func(ctx context.Context) error {
+		cur, err := repo.getTodo(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := authorizeTodo(ctx, repo.db, user, cur.OwnerID, authz.ActionWrite); err != nil {
+			return err
+		}
+
+		res, err = scan(repo.db.QueryRowContext(ctx, q, model.ID, model.Message, model.IsDone))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTodoNotFound
+		}
+
+		return err
+	})
+
+	return res, err
 }
 
-// GetTodos retrives one todo by its ID or throws an error.
-func (repo TodoRepo) GetTodo(ctx context.Context, id uuid.UUID) (model.Todo, error) {
+// ArchiveTodos archives the todos completed before the given date and returns
+// how many of them were archived.
+func (repo TodoRepo) ArchiveTodos(ctx context.Context, before time.Time) (int64, error) {
+	defer observe("TodoRepo", "ArchiveTodos")()
+
 	const q = `
-		SELECT id, created_at, is_done, message
+		UPDATE todo
+		SET archived_at = NOW()
+		WHERE is_done AND archived_at IS NULL AND completed_at < $1
+	`
+
+	res, err := exec(ctx, repo.db, q, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// getTodo retrieves one todo by its ID regardless of who can see it.
+func (repo TodoRepo) getTodo(ctx context.Context, id uuid.UUID) (model.Todo, error) {
+	const q = `
+		SELECT id, created_at, is_done, message, completed_at, archived_at, owner_id
 		FROM todo
 		WHERE id = $1
 	`
@@ -51,23 +202,60 @@ func (repo TodoRepo) GetTodo(ctx context.Context, id uuid.UUID) (model.Todo, err
 	return res, err
 }
 
-// AddTodo adds a todo model.
-func (repo TodoRepo) AddTodo(ctx context.Context, model model.Todo) (model.Todo, error) {
-	model.ID = uuid.New()
-	model.CreatedAt = time.Now()
+// listRole returns the role of the user on the todo list of the owner. The
+// membership is locked until the end of the transaction, so that it can not
+// be revoked before the queries it authorizes run.
+func listRole(ctx context.Context, db DBTX, user, owner uuid.UUID) (authz.Role, error) {
+	if user == owner {
+		return authz.RoleOwner, nil
+	}
 
 	const q = `
-		INSERT INTO todo (id, created_at, is_done, message)
-		VALUES ($1, $2, $3, $4)
+		SELECT role
+		FROM list_member
+		WHERE owner_id = $1 AND user_id = $2 AND accepted_at IS NOT NULL
+		FOR SHARE
 	`
 
-	_, err := repo.db.ExecContext(ctx, q, model.ID, model.CreatedAt, model.IsDone, model.Message)
+	var role authz.Role
+	err := db.QueryRowContext(ctx, q, owner, user).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return authz.RoleNone, nil
+	}
 
-	return model, err
+	return role, err
+}
+
+// authorizeList checks the user can perform the action on the todo list of
+// the owner, or throws an error.
+func authorizeList(ctx context.Context, db DBTX, user, owner uuid.UUID, action authz.Action) error {
+	return authorize(ctx, db, user, owner, action, ErrListNotFound)
+}
+
+// authorizeTodo checks the user can perform the action on a todo of the list
+// of the owner, or throws an error.
+func authorizeTodo(ctx context.Context, db DBTX, user, owner uuid.UUID, action authz.Action) error {
+	return authorize(ctx, db, user, owner, action, ErrTodoNotFound)
+}
+
+// authorize checks the user can perform the action on the todo list of the
+// owner. Users who can not see the list get notFound, as if it did not exist.
+func authorize(ctx context.Context, db DBTX, user, owner uuid.UUID, action authz.Action, notFound error) error {
+	role, err := listRole(ctx, db, user, owner)
+	if err != nil {
+		return err
+	}
+
+	err = authz.Authorize(role, action)
+	if errors.Is(err, authz.ErrNotFound) {
+		return notFound
+	}
+
+	return err
 }
 
 func scan(row scanner) (model.Todo, error) {
 	var val model.Todo
-	err := row.Scan(&val.ID, &val.CreatedAt, &val.IsDone, &val.Message)
+	err := row.Scan(&val.ID, &val.CreatedAt, &val.IsDone, &val.Message, &val.CompletedAt, &val.ArchivedAt, &val.OwnerID)
 	return val, err
 }
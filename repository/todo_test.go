@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/authz"
 	"github.com/iciantoine/todo-go-api/model"
 	"github.com/iciantoine/todo-go-api/repository"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -17,12 +18,21 @@ import (
 
 const pgTimestamptzHourFormat = "2006-01-02 15:04:05.999999999Z07"
 
+var (
+	john = uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	jane = uuid.MustParse("9b2f4c1e-3d5a-4e8b-8f7c-1a2b3c4d5e6f")
+
+	defaultOrg = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	acmeOrg    = uuid.MustParse("a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a")
+	acmeJohn   = uuid.MustParse("4f6d8b2a-1c3e-4a5b-8d7f-9e0a1b2c3d4e")
+)
+
 func TestGetTodos(t *testing.T) {
 	t.Run("it should return a list of todos", func(t *testing.T) {
 		SUT, teardown := setup(t)
 		defer teardown()
 
-		res, err := SUT.GetTodos(context.Background())
+		res, err := SUT.GetTodos(context.Background(), john, john, false)
 		assert.NoError(t, err)
 		assert.Len(t, res, 2)
 
@@ -38,6 +48,91 @@ func TestGetTodos(t *testing.T) {
 		assert.False(t, res[1].IsDone)
 		assert.Equal(t, "Test", res[1].Message)
 	})
+
+	t.Run("it should only return the todos of the owner", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetTodos(context.Background(), jane, jane, true)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, jane, res[0].OwnerID)
+	})
+
+	t.Run("it should include archived todos", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetTodos(context.Background(), john, john, true)
+		assert.NoError(t, err)
+		assert.Len(t, res, 3)
+
+		assert.Equal(t, uuid.MustParse("5d1b0e5c-0f0a-4d49-9a3c-3b4cdb0c7a1e"), res[2].ID)
+		assert.NotNil(t, res[2].ArchivedAt)
+	})
+
+	t.Run("it should return the todos of a list shared with the user", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetTodos(context.Background(), jane, john, false)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, john, res[0].OwnerID)
+	})
+
+	t.Run("it should return a not found error for lists not shared with the user", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.GetTodos(context.Background(), john, jane, false)
+		assert.ErrorIs(t, err, repository.ErrListNotFound)
+	})
+}
+
+func TestGetArchivedTodos(t *testing.T) {
+	t.Run("it should return the archived todos", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetArchivedTodos(context.Background(), john, john)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+
+		assert.Equal(t, uuid.MustParse("5d1b0e5c-0f0a-4d49-9a3c-3b4cdb0c7a1e"), res[0].ID)
+		aDate, _ := time.Parse(pgTimestamptzHourFormat, "2023-02-01 00:00:00.000000+00")
+		assert.Equal(t, aDate.Local(), *res[0].ArchivedAt)
+	})
+}
+
+func TestArchiveTodos(t *testing.T) {
+	t.Run("it should archive todos completed before the given date", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		before, _ := time.Parse(pgTimestamptzHourFormat, "2023-03-07 00:00:00.000000+00")
+		n, err := SUT.ArchiveTodos(context.Background(), before)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		res, err := SUT.GetTodo(context.Background(), john, uuid.MustParse("169e84e3-35d9-4476-8295-2c28c54d50fc"))
+		assert.NoError(t, err)
+		assert.NotNil(t, res.ArchivedAt)
+
+		res, err = SUT.GetTodo(context.Background(), john, uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"))
+		assert.NoError(t, err)
+		assert.Nil(t, res.ArchivedAt)
+	})
+
+	t.Run("it should not archive recently completed todos", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		before, _ := time.Parse(pgTimestamptzHourFormat, "2023-03-06 13:00:00.000000+00")
+		n, err := SUT.ArchiveTodos(context.Background(), before)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
 }
 
 func TestGetTodo(t *testing.T) {
@@ -45,7 +140,7 @@ func TestGetTodo(t *testing.T) {
 		SUT, teardown := setup(t)
 		defer teardown()
 
-		res, err := SUT.GetTodo(context.Background(), uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"))
+		res, err := SUT.GetTodo(context.Background(), john, uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"))
 		assert.NoError(t, err)
 		cDate, _ := time.Parse(pgTimestamptzHourFormat, "2023-03-06 12:00:00.000000+00")
 		assert.Equal(t, cDate.Local(), res.CreatedAt)
@@ -57,9 +152,26 @@ func TestGetTodo(t *testing.T) {
 		SUT, teardown := setup(t)
 		defer teardown()
 
-		_, err := SUT.GetTodo(context.Background(), uuid.New())
+		_, err := SUT.GetTodo(context.Background(), john, uuid.New())
+		assert.ErrorIs(t, repository.ErrTodoNotFound, err)
+	})
+
+	t.Run("it should return a not found error for todos of other owners", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.GetTodo(context.Background(), john, uuid.MustParse("e1d2c3b4-a596-4877-8695-a4b3c2d1e0f9"))
 		assert.ErrorIs(t, repository.ErrTodoNotFound, err)
 	})
+
+	t.Run("it should return a todo of a list shared with the user", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetTodo(context.Background(), jane, uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"))
+		assert.NoError(t, err)
+		assert.Equal(t, john, res.OwnerID)
+	})
 }
 
 func TestAddTodo(t *testing.T) {
@@ -67,16 +179,154 @@ func TestAddTodo(t *testing.T) {
 		SUT, teardown := setup(t)
 		defer teardown()
 
-		res, err := SUT.AddTodo(context.Background(), model.Todo{
+		res, err := SUT.AddTodo(context.Background(), john, john, model.Todo{
 			IsDone:  true,
 			Message: "test",
 		})
 		assert.NoError(t, err)
 
 		assert.NotEmpty(t, res.ID.String())
+		assert.Equal(t, john, res.OwnerID)
 		assert.True(t, res.IsDone)
 		assert.Equal(t, "test", res.Message)
 		assert.NotZero(t, res.CreatedAt)
+		assert.Equal(t, &res.CreatedAt, res.CompletedAt)
+		assert.Nil(t, res.ArchivedAt)
+	})
+
+	t.Run("it should return a forbidden error for viewers of the list", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.AddTodo(context.Background(), jane, john, model.Todo{Message: "test"})
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+
+	t.Run("it should return a not found error for lists not shared with the user", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.AddTodo(context.Background(), john, jane, model.Todo{Message: "test"})
+		assert.ErrorIs(t, err, repository.ErrListNotFound)
+	})
+}
+
+func TestUpdateTodo(t *testing.T) {
+	t.Run("it should update a todo and return it", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.UpdateTodo(context.Background(), john, model.Todo{
+			ID:      uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"),
+			IsDone:  true,
+			Message: "updated",
+		})
+		assert.NoError(t, err)
+		assert.True(t, res.IsDone)
+		assert.Equal(t, "updated", res.Message)
+		assert.NotNil(t, res.CompletedAt)
+		assert.Equal(t, john, res.OwnerID)
+	})
+
+	t.Run("it should clear the completion date of reopened todos", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.UpdateTodo(context.Background(), john, model.Todo{
+			ID:      uuid.MustParse("169e84e3-35d9-4476-8295-2c28c54d50fc"),
+			Message: "Lorem ipsum",
+		})
+		assert.NoError(t, err)
+		assert.False(t, res.IsDone)
+		assert.Nil(t, res.CompletedAt)
+	})
+
+	t.Run("it should unarchive reopened todos", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.UpdateTodo(context.Background(), john, model.Todo{
+			ID:      uuid.MustParse("5d1b0e5c-0f0a-4d49-9a3c-3b4cdb0c7a1e"),
+			Message: "reopened",
+		})
+		assert.NoError(t, err)
+		assert.False(t, res.IsDone)
+		assert.Nil(t, res.CompletedAt)
+		assert.Nil(t, res.ArchivedAt)
+
+		archived, err := SUT.GetArchivedTodos(context.Background(), john, john)
+		assert.NoError(t, err)
+		assert.Empty(t, archived)
+
+		todos, err := SUT.GetTodos(context.Background(), john, john, false)
+		assert.NoError(t, err)
+		assert.Len(t, todos, 3)
+	})
+
+	t.Run("it should return a forbidden error for viewers of the list", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.UpdateTodo(context.Background(), jane, model.Todo{
+			ID:      uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"),
+			Message: "updated",
+		})
+		assert.ErrorIs(t, err, authz.ErrForbidden)
+	})
+
+	t.Run("it should return a not found error for todos of other owners", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		_, err := SUT.UpdateTodo(context.Background(), john, model.Todo{
+			ID:      uuid.MustParse("e1d2c3b4-a596-4877-8695-a4b3c2d1e0f9"),
+			Message: "updated",
+		})
+		assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	})
+}
+
+func TestTodoRepoIsolation(t *testing.T) {
+	t.Run("it should not return the todos of other organizations", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		res, err := SUT.GetTodos(context.Background(), acmeJohn, acmeJohn, true)
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+
+		_, err = SUT.GetTodo(context.Background(), acmeJohn, uuid.MustParse("b7e9f1a3-5c2d-4e6f-8a9b-0c1d2e3f4a5b"))
+		assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	})
+
+	t.Run("it should return the todos of its organization", func(t *testing.T) {
+		db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+		assert.NoError(t, err)
+		defer db.Close()
+
+		tx, err := db.BeginTx(context.Background(), nil)
+		assert.NoError(t, err)
+		defer tx.Rollback()
+		setOrg(t, tx, acmeOrg)
+
+		SUT := repository.NewTodoRepo(tx)
+
+		res, err := SUT.GetTodos(context.Background(), acmeJohn, acmeJohn, true)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+
+		// the owner of the todos of the default organization can not see them either
+		_, err = SUT.GetTodo(context.Background(), john, uuid.MustParse("038863e4-2fbe-4bc3-9e38-1e62e93659f5"))
+		assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	})
+
+	t.Run("it should not archive the todos of other organizations", func(t *testing.T) {
+		SUT, teardown := setup(t)
+		defer teardown()
+
+		n, err := SUT.ArchiveTodos(context.Background(), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
 	})
 }
 
@@ -86,6 +336,7 @@ func setup(t *testing.T) (repository.TodoRepo, func()) {
 
 	tx, err := db.BeginTx(context.Background(), nil)
 	assert.NoError(t, err)
+	setOrg(t, tx, defaultOrg)
 
 	SUT := repository.NewTodoRepo(tx)
 
@@ -94,3 +345,10 @@ func setup(t *testing.T) (repository.TodoRepo, func()) {
 		assert.NoError(t, db.Close())
 	}
 }
+
+// setOrg restricts the queries of the transaction to the rows of the
+// organization.
+func setOrg(t *testing.T, tx *sql.Tx, org uuid.UUID) {
+	_, err := tx.Exec(`SELECT set_config('app.current_org', $1, TRUE)`, org.String())
+	assert.NoError(t, err)
+}
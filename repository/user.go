@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserExists      = errors.New("user already exists")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// UserRepo is the user repository.
+type UserRepo struct {
+	db DBTX
+}
+
+// NewUserRepo instantiates UserRepo.
+func NewUserRepo(db DBTX) UserRepo {
+	return UserRepo{
+		db: db,
+	}
+}
+
+// AddUser adds a user model or throws an error if the email is already taken.
+func (repo UserRepo) AddUser(ctx context.Context, model model.User) (model.User, error) {
+	defer observe("UserRepo", "AddUser")()
+
+	model.ID = uuid.New()
+	model.CreatedAt = time.Now()
+
+	const q = `
+		INSERT INTO users (id, created_at, email, password_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (org_id, email) DO NOTHING
+	`
+
+	res, err := exec(ctx, repo.db, q, model.ID, model.CreatedAt, model.Email, model.PasswordHash)
+	if err != nil {
+		return model, err
+	}
+
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = ErrUserExists
+	}
+
+	return model, err
+}
+
+// GetUserByEmail retrieves one user by its email or throws an error.
+func (repo UserRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	defer observe("UserRepo", "GetUserByEmail")()
+
+	const q = `
+		SELECT id, created_at, email, password_hash
+		FROM users
+		WHERE email = $1
+	`
+
+	res, err := queryRow(ctx, repo.db, scanUser, q, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, ErrUserNotFound
+	}
+
+	return res, err
+}
+
+// AddSession adds a login session for the given user.
+func (repo UserRepo) AddSession(ctx context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) error {
+	defer observe("UserRepo", "AddSession")()
+
+	const q = `
+		INSERT INTO session (token_hash, user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := exec(ctx, repo.db, q, tokenHash, userID, time.Now(), expiresAt)
+
+	return err
+}
+
+// GetSessionUser retrieves the user of a non expired session or throws an error.
+func (repo UserRepo) GetSessionUser(ctx context.Context, tokenHash []byte) (model.User, error) {
+	defer observe("UserRepo", "GetSessionUser")()
+
+	const q = `
+		SELECT users.id, users.created_at, users.email, users.password_hash
+		FROM session
+		JOIN users ON users.id = session.user_id
+		WHERE session.token_hash = $1 AND session.expires_at > NOW()
+	`
+
+	res, err := queryRow(ctx, repo.db, scanUser, q, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, ErrSessionNotFound
+	}
+
+	return res, err
+}
+
+func scanUser(row scanner) (model.User, error) {
+	var val model.User
+	err := row.Scan(&val.ID, &val.CreatedAt, &val.Email, &val.PasswordHash)
+	return val, err
+}
@@ -0,0 +1,112 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddUser(t *testing.T) {
+	t.Run("it should add a user and return it", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		res, err := SUT.AddUser(context.Background(), model.User{
+			Email:        "test@example.com",
+			PasswordHash: "hash",
+		})
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, uuid.Nil, res.ID)
+		assert.NotZero(t, res.CreatedAt)
+		assert.Equal(t, "test@example.com", res.Email)
+	})
+
+	t.Run("it should return an error on already registered email", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		_, err := SUT.AddUser(context.Background(), model.User{
+			Email:        "john@example.com",
+			PasswordHash: "hash",
+		})
+		assert.ErrorIs(t, err, repository.ErrUserExists)
+	})
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	t.Run("it should return a user", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		res, err := SUT.GetUserByEmail(context.Background(), "john@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, john, res.ID)
+		assert.NotEmpty(t, res.PasswordHash)
+	})
+
+	t.Run("it should return a not found error", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		_, err := SUT.GetUserByEmail(context.Background(), "test@example.com")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+}
+
+func TestGetSessionUser(t *testing.T) {
+	t.Run("it should return the user of a session", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		err := SUT.AddSession(context.Background(), john, []byte("hash"), time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+
+		res, err := SUT.GetSessionUser(context.Background(), []byte("hash"))
+		assert.NoError(t, err)
+		assert.Equal(t, john, res.ID)
+	})
+
+	t.Run("it should return a not found error on expired session", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		err := SUT.AddSession(context.Background(), john, []byte("hash"), time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+
+		_, err = SUT.GetSessionUser(context.Background(), []byte("hash"))
+		assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+	})
+
+	t.Run("it should return a not found error on unknown session", func(t *testing.T) {
+		SUT, teardown := setupUser(t)
+		defer teardown()
+
+		_, err := SUT.GetSessionUser(context.Background(), []byte("hash"))
+		assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+	})
+}
+
+func setupUser(t *testing.T) (repository.UserRepo, func()) {
+	db, err := sql.Open("pgx", "host=localhost port=5432 user=todo password=todo dbname=todo sslmode=disable")
+	assert.NoError(t, err)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	assert.NoError(t, err)
+	setOrg(t, tx, defaultOrg)
+
+	SUT := repository.NewUserRepo(tx)
+
+	return SUT, func() {
+		assert.NoError(t, tx.Rollback())
+		assert.NoError(t, db.Close())
+	}
+}
@@ -0,0 +1,69 @@
+// Package requestid identifies requests, to correlate their logs and
+// responses.
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Header is the header carrying the request ID.
+const Header = "X-Request-ID"
+
+// maxLength is the maximum length of request IDs sent by clients.
+const maxLength = 128
+
+type idKey struct{}
+
+// WithID returns a copy of ctx carrying the request ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok
+}
+
+// Middleware identifies requests with the ID of their X-Request-ID header, or
+// a generated one when it is missing or not valid, and echoes it in the
+// response. The request context carries the ID and a logger annotated with
+// the ID, method and route of the request, see zerolog.Ctx.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(Header)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+		ctx.Header(Header, id)
+
+		logger := log.Logger.With().
+			Str("request_id", id).
+			Str("method", ctx.Request.Method).
+			Str("route", ctx.FullPath()).
+			Logger()
+
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(WithID(ctx.Request.Context(), id)))
+		ctx.Next()
+	}
+}
+
+// valid reports whether a request ID sent by a client is short and made of
+// printable ASCII characters only, so that it can not forge log lines.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package requestid_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/requestid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buf := new(bytes.Buffer)
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	log.Logger = zerolog.New(buf)
+
+	serve := func(header string) (*httptest.ResponseRecorder, string) {
+		var got string
+
+		router := gin.New()
+		router.Use(requestid.Middleware())
+		router.GET("/todo", func(ctx *gin.Context) {
+			got, _ = requestid.FromContext(ctx.Request.Context())
+			log.Ctx(ctx.Request.Context()).Info().Msg("test")
+			ctx.Status(http.StatusNoContent)
+		})
+
+		buf.Reset()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/todo", http.NoBody)
+		if header != "" {
+			req.Header.Set(requestid.Header, header)
+		}
+		router.ServeHTTP(rr, req)
+
+		return rr, got
+	}
+
+	t.Run("keeps the request ID of the header", func(t *testing.T) {
+		rr, got := serve("test-id")
+		assert.Equal(t, "test-id", got)
+		assert.Equal(t, "test-id", rr.Header().Get(requestid.Header))
+	})
+
+	t.Run("annotates the context logger", func(t *testing.T) {
+		serve("test-id")
+		assert.Equal(t, "test-id", gjson.Get(buf.String(), "request_id").String())
+		assert.Equal(t, "GET", gjson.Get(buf.String(), "method").String())
+		assert.Equal(t, "/todo", gjson.Get(buf.String(), "route").String())
+	})
+
+	for name, header := range map[string]string{
+		"missing":   "",
+		"too long":  strings.Repeat("a", 129),
+		"forged":    "test\n{\"level\":\"error\"}",
+		"with tabs": "test\tid",
+	} {
+		t.Run("generates the request ID when "+name, func(t *testing.T) {
+			rr, got := serve(header)
+			_, err := uuid.Parse(got)
+			assert.NoError(t, err)
+			assert.Equal(t, got, rr.Header().Get(requestid.Header))
+		})
+	}
+}
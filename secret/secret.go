@@ -0,0 +1,91 @@
+// Package secret provides secrets such as passwords from where they are
+// deployed, so that they can be rotated without restart.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider provides the current value of a secret.
+type Provider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// File is a secret held by the file at the given path, such as a Docker or
+// Kubernetes mounted secret. Trailing line breaks are trimmed.
+type File string
+
+// Secret implements Provider.
+func (f File) Secret(context.Context) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("could not read secret file: %w", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Env is a secret held by the environment variable of the given name.
+type Env string
+
+// Secret implements Provider.
+func (e Env) Secret(context.Context) (string, error) {
+	val, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("missing secret environment variable %s", string(e))
+	}
+
+	return val, nil
+}
+
+// Value is a secret known in advance.
+type Value string
+
+// Secret implements Provider.
+func (v Value) Secret(context.Context) (string, error) {
+	return string(v), nil
+}
+
+// Cache caches the secret of a provider until reloaded.
+type Cache struct {
+	mu  sync.RWMutex
+	val string
+}
+
+// NewCache instantiates Cache with the current secret of provider.
+func NewCache(ctx context.Context, provider Provider) (*Cache, error) {
+	c := &Cache{}
+	if _, err := c.Use(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Secret implements Provider.
+func (c *Cache) Secret(context.Context) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.val, nil
+}
+
+// Use reads the secret of a provider again, or switches to the one of
+// another provider, such as on configuration reload, and reports whether it
+// changed. The previous secret is kept when it can not be read.
+func (c *Cache) Use(ctx context.Context, provider Provider) (bool, error) {
+	val, err := provider.Secret(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := val != c.val
+	c.val = val
+	return changed, nil
+}
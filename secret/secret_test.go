@@ -0,0 +1,95 @@
+package secret_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	val string
+	err error
+}
+
+func (sp *stubProvider) Secret(context.Context) (string, error) {
+	return sp.val, sp.err
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\r\n"), 0o600))
+
+	val, err := secret.File(path).Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", val)
+
+	_, err = secret.File(filepath.Join(t.TempDir(), "missing")).Secret(context.Background())
+	assert.Error(t, err)
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("TEST_SECRET", "s3cret")
+
+	val, err := secret.Env("TEST_SECRET").Secret(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", val)
+
+	_, err = secret.Env("TEST_MISSING_SECRET").Secret(context.Background())
+	assert.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	t.Run("caches the secret until reloaded", func(t *testing.T) {
+		p := &stubProvider{val: "old"}
+		c, err := secret.NewCache(context.Background(), p)
+		require.NoError(t, err)
+
+		p.val = "new"
+		val, _ := c.Secret(context.Background())
+		assert.Equal(t, "old", val)
+
+		changed, err := c.Use(context.Background(), p)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		val, _ = c.Secret(context.Background())
+		assert.Equal(t, "new", val)
+	})
+
+	t.Run("switches to another provider", func(t *testing.T) {
+		c, err := secret.NewCache(context.Background(), secret.Value("old"))
+		require.NoError(t, err)
+
+		changed, err := c.Use(context.Background(), secret.Value("new"))
+		assert.NoError(t, err)
+		assert.True(t, changed)
+
+		changed, err = c.Use(context.Background(), secret.Value("new"))
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		val, _ := c.Secret(context.Background())
+		assert.Equal(t, "new", val)
+	})
+
+	t.Run("keeps the previous secret when reloading fails", func(t *testing.T) {
+		p := &stubProvider{val: "old"}
+		c, err := secret.NewCache(context.Background(), p)
+		require.NoError(t, err)
+
+		p.err = errors.New("test")
+		_, err = c.Use(context.Background(), p)
+		assert.Error(t, err)
+		val, _ := c.Secret(context.Background())
+		assert.Equal(t, "old", val)
+	})
+
+	t.Run("error when the secret can not be read", func(t *testing.T) {
+		_, err := secret.NewCache(context.Background(), &stubProvider{err: errors.New("test")})
+		assert.Error(t, err)
+	})
+}
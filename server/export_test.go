@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/health"
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/iciantoine/todo-go-api/secret"
+)
+
+// Serve serves requests of srv from ln, see serve.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, hc *health.Health, cfg option.Shutdown) error {
+	return serve(ctx, hc, cfg, listener{srv: srv, ln: ln})
+}
+
+// Redirect exports redirect.
+var Redirect = redirect
+
+// Reload loads the options of load as the server would on reload, starting
+// from opts, and returns the database password then in use.
+func Reload(ctx context.Context, load func() ([]Option, error), opts ...Option) (string, error) {
+	cfg, err := configure(append(opts, WithReload(nil, load))...)
+	if err != nil {
+		return "", err
+	}
+	if err := configureLogging(cfg); err != nil {
+		return "", err
+	}
+
+	dbPassword, err := secret.NewCache(ctx, dbPasswordOf(cfg))
+	if err != nil {
+		return "", err
+	}
+
+	r := &reloader{cfg: cfg, dbPassword: dbPassword}
+	r.reload(ctx)
+	return dbPassword.Secret(ctx)
+}
+
+// ReadOnly returns the middleware of a schema guard serving read-only.
+func ReadOnly() gin.HandlerFunc {
+	g := &schemaGuard{readOnly: true}
+	g.behind.Store(true)
+	return g.middleware()
+}
@@ -0,0 +1,39 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := map[string]struct {
+		port     string
+		target   string
+		location string
+	}{
+		"keeps path and query": {
+			port:     "8443",
+			target:   "http://example.com:8080/todo?owner_id=1",
+			location: "https://example.com:8443/todo?owner_id=1",
+		},
+		"omits the default port": {
+			port:     "443",
+			target:   "http://example.com/todo",
+			location: "https://example.com/todo",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.Redirect(tt.port).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
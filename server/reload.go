@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/iciantoine/todo-go-api/tlsconfig"
+	"github.com/rs/zerolog/log"
+)
+
+// reloader applies the settings that can change without restart: the log
+// level, the TLS certificate and the database password. Connections are kept.
+type reloader struct {
+	cfg        *config
+	dbPassword *secret.Cache
+	// certs is nil when serving plain HTTP
+	certs *tlsconfig.CertReloader
+}
+
+// reload loads the configuration again, when the server was given a way to,
+// then reads the TLS certificate and secrets again. Settings that can not be
+// reloaded keep their current value, as well as the whole configuration when
+// it is not valid.
+func (r *reloader) reload(ctx context.Context) {
+	log.Info().Msg("reloading")
+
+	if r.cfg.Load != nil {
+		if next, err := r.next(); err != nil {
+			log.Error().Err(err).Msg("could not reload configuration, keeping the current one")
+		} else {
+			switch {
+			case r.certs == nil && next.TLS.Enabled():
+				log.Warn().Msg("enabling TLS requires a restart, serving plain HTTP until then")
+			case r.certs != nil && !next.TLS.Enabled():
+				log.Warn().Msg("disabling TLS requires a restart, serving the current certificate until then")
+			}
+			if next.LogLevel != r.cfg.LogLevel {
+				log.Info().Str("level", next.LogLevel).Msg("changing log level")
+			}
+			if err := configureLogging(next); err != nil {
+				log.Error().Err(err).Msg("could not configure logging")
+			}
+			r.cfg = next
+		}
+	}
+
+	changed, err := r.dbPassword.Use(ctx, dbPasswordOf(r.cfg))
+	switch {
+	case err != nil:
+		log.Error().Err(err).Msg("could not reload database password")
+	case changed:
+		log.Info().Msg("reloaded database password, used by new connections")
+	}
+
+	if r.certs != nil && r.cfg.TLS.Enabled() {
+		if err := r.certs.Use(r.cfg.TLS.CertFile, r.cfg.TLS.KeyFile); err != nil {
+			log.Error().Err(err).Msg("could not reload TLS certificate")
+		}
+	}
+}
+
+// next returns the configuration loaded again, without applying it.
+func (r *reloader) next() (*config, error) {
+	opts, err := r.cfg.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := configure(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the reload channel and loader are not part of the loaded options
+	next.Reload, next.Load = r.cfg.Reload, r.cfg.Load
+	return next, nil
+}
+
+// dbPasswordOf returns the provider of the database password of cfg, an
+// empty one when not configured.
+func dbPasswordOf(cfg *config) secret.Provider {
+	if cfg.DBPassword == nil {
+		return secret.Value("")
+	}
+	return cfg.DBPassword
+}
@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/iciantoine/todo-go-api/server"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	initial := []server.Option{
+		server.WithLogLevel("debug"),
+		server.WithDatabasePassword(secret.Value("old")),
+	}
+
+	t.Run("applies the log level and password", func(t *testing.T) {
+		pass, err := server.Reload(context.Background(), func() ([]server.Option, error) {
+			return []server.Option{
+				server.WithLogLevel("warn"),
+				server.WithDatabasePassword(secret.Value("new")),
+			}, nil
+		}, initial...)
+		require.NoError(t, err)
+		assert.Equal(t, "new", pass)
+		assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+	})
+
+	t.Run("warns that enabling TLS requires a restart", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+		log.Logger = zerolog.New(buf)
+
+		_, err := server.Reload(context.Background(), func() ([]server.Option, error) {
+			return append(initial, server.WithTLS("server.crt", "server.key")), nil
+		}, initial...)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "enabling TLS requires a restart")
+	})
+
+	for name, load := range map[string]func() ([]server.Option, error){
+		"load error": func() ([]server.Option, error) {
+			return nil, errors.New("test")
+		},
+		"invalid configuration": func() ([]server.Option, error) {
+			return []server.Option{
+				server.WithLogLevel("warn"),
+				server.WithDatabasePassword(secret.Value("new")),
+				server.WithHTTPRedirect("8081"),
+			}, nil
+		},
+	} {
+		t.Run("keeps the current configuration on "+name, func(t *testing.T) {
+			pass, err := server.Reload(context.Background(), load, initial...)
+			require.NoError(t, err)
+			assert.Equal(t, "old", pass)
+			assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/database"
+	"github.com/iciantoine/todo-go-api/health"
+)
+
+// How the server handles a database schema behind the version it expects,
+// see WithSchemaBehind.
+const (
+	schemaBehindFail     = "fail"
+	schemaBehindReadOnly = "read-only"
+)
+
+// schemaGuard serves requests read-only while the database schema is behind,
+// when configured to, so that writes do not fail on missing tables or
+// columns.
+type schemaGuard struct {
+	db       *sql.DB
+	readOnly bool
+	// behind tells whether the schema was behind when last checked
+	behind atomic.Bool
+}
+
+// check checks the version of the database schema, and serves requests
+// read-only or not accordingly. A schema behind is a warning when served
+// read-only, so that the server stays ready. A schema ahead is always a
+// warning: migrations are rolled out before the binaries expecting them, and
+// keep the schema compatible with the previous binary.
+func (g *schemaGuard) check(ctx context.Context) error {
+	err := database.CheckSchema(ctx, g.db)
+	switch {
+	case errors.Is(err, database.ErrSchemaAhead):
+		g.behind.Store(false)
+		return health.Warning{Err: err}
+	case errors.Is(err, database.ErrSchemaBehind):
+		g.behind.Store(true)
+		if g.readOnly {
+			return health.Warning{Err: fmt.Errorf("serving read-only: %w", err)}
+		}
+	case err == nil:
+		g.behind.Store(false)
+	}
+
+	return err
+}
+
+// middleware answers 503 to the requests that write while the schema is
+// behind.
+func (g *schemaGuard) middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if g.behind.Load() {
+				ctx.AbortWithStatus(http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(server.ReadOnly())
+	router.Any("/todo", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for method, status := range map[string]int{
+		http.MethodGet:    http.StatusOK,
+		http.MethodHead:   http.StatusOK,
+		http.MethodPost:   http.StatusServiceUnavailable,
+		http.MethodPut:    http.StatusServiceUnavailable,
+		http.MethodDelete: http.StatusServiceUnavailable,
+	} {
+		t.Run(method, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(method, "/todo", http.NoBody))
+			assert.Equal(t, status, rr.Code)
+		})
+	}
+}
@@ -2,50 +2,658 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/accesslog"
+	"github.com/iciantoine/todo-go-api/archive"
+	"github.com/iciantoine/todo-go-api/auth"
 	"github.com/iciantoine/todo-go-api/database"
 	"github.com/iciantoine/todo-go-api/handler"
+	"github.com/iciantoine/todo-go-api/health"
+	"github.com/iciantoine/todo-go-api/metrics"
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/iciantoine/todo-go-api/problem"
+	"github.com/iciantoine/todo-go-api/ratelimit"
 	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/requestid"
+	"github.com/iciantoine/todo-go-api/secret"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/iciantoine/todo-go-api/tlsconfig"
+	"github.com/iciantoine/todo-go-api/tracing"
 	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// Listen starts the HTTP server.
+const (
+	defaultSessionTTL = 24 * time.Hour
+	defaultOrgHeader  = "X-Org-ID"
+	serviceName       = "todo-go-api"
+	flushTimeout      = 5 * time.Second
+	readinessTimeout  = 2 * time.Second
+	readHeaderTimeout = 10 * time.Second
+
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// Listen starts the HTTP server and serves requests until the parent context
+// is closed, then shuts down gracefully, see serve. It returns nil once shut
+// down in order.
 func Listen(parent context.Context, opts ...Option) error {
-	cfg := new(config)
+	cfg, err := configure(opts...)
+	if err != nil {
+		log.Error().Err(err).Msg("could not configure application")
+		return err
+	}
+	if err := configureLogging(cfg); err != nil {
+		log.Error().Err(err).Msg("could not configure logging")
+		return err
+	}
 
-	for _, opt := range opts {
-		if err := opt(cfg); err != nil {
-			log.Error().Err(err).Msg("could not configure application")
-			return err
+	if cfg.LogFile.Path != "" {
+		file := &lumberjack.Logger{
+			Filename:   cfg.LogFile.Path,
+			MaxSize:    cfg.LogFile.MaxSizeMB,
+			MaxBackups: cfg.LogFile.MaxBackups,
+			MaxAge:     cfg.LogFile.MaxAgeDays,
 		}
+		defer file.Close()
+
+		defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+		log.Logger = log.Output(file)
 	}
 
-	conn, err := database.Connect("pgx", cfg.Database.DSN())
+	shutdown, err := tracing.Setup(parent, cfg.Tracing)
+	if err != nil {
+		log.Error().Err(err).Msg("could not set up tracing")
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("could not flush traces")
+		}
+	}()
+
+	conn, dbPassword, err := connect(parent, cfg)
 	if err != nil {
-		log.Error().Err(err).Msg("could not connect to Lydia database")
 		return err
 	}
 	defer conn.Close()
 
-	trepo := repository.NewTodoRepo(conn)
+	if cfg.AutoMigrate {
+		if err := migrate(parent, conn); err != nil {
+			return err
+		}
+	}
+
+	schema := &schemaGuard{db: conn, readOnly: cfg.SchemaBehind == schemaBehindReadOnly}
+	switch err := schema.check(parent); {
+	case errors.Is(err, database.ErrSchemaAhead):
+		log.Warn().Err(err).Msg("database schema is ahead, migrated for a newer binary")
+	case errors.Is(err, database.ErrSchemaBehind) && schema.readOnly:
+		log.Warn().Err(err).Msg("database schema is behind, answering 503 to writes until migrated")
+	case err != nil:
+		log.Error().Err(err).Msg("database schema does not match, see server migrate")
+		return err
+	}
+
+	// queries run in transactions of the organization of their context, see
+	// tenant.Middleware
+	db := tracing.NewDB(database.NewOrgDB(conn, tenant.OrgFrom))
+
+	trepo := repository.NewTodoRepo(db)
+	srepo := repository.NewStatsRepo(db)
+	urepo := repository.NewUserRepo(db)
+	krepo := repository.NewAPIKeyRepo(db)
+	mrepo := repository.NewMemberRepo(db)
+	orepo := repository.NewOrganizationRepo(conn)
+
+	// background workers are stopped and waited for before the connection
+	// pool is closed
+	ctx, cancel := context.WithCancel(parent)
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer cancel()
+
+	// archiving writes, and would fail until restarted on the migrated schema
+	if cfg.Archive.After > 0 && !schema.behind.Load() {
+		arepo := orgArchiveRepo{orgs: orepo, todos: trepo}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			archive.NewArchiver(arepo, cfg.Archive.After, cfg.Archive.Interval).Run(ctx)
+		}()
+	}
+
+	authns := []auth.Authenticator{
+		auth.NewAPIKeyAuthenticator(krepo),
+		auth.NewSessionAuthenticator(urepo),
+	}
+
+	if cfg.JWT.Enabled() {
+		keys, err := jwtKeys(cfg.JWT)
+		if err != nil {
+			log.Error().Err(err).Msg("could not load JWT keys")
+			return err
+		}
+
+		authns = append([]auth.Authenticator{auth.NewJWTAuthenticator(keys, cfg.JWT.Issuer, cfg.JWT.Audience, auth.ClaimMapping{})}, authns...)
+	}
+
+	if cfg.OIDC.Enabled() {
+		provider, err := auth.NewOIDCProvider(cfg.OIDC.IssuerURL)
+		if err != nil {
+			log.Error().Err(err).Msg("could not discover OIDC provider")
+			return err
+		}
+
+		mapping := auth.ClaimMapping{
+			Email:  cfg.OIDC.EmailClaim,
+			Groups: cfg.OIDC.GroupsClaim,
+			Org:    cfg.OIDC.OrgClaim,
+		}
+		authns = append([]auth.Authenticator{auth.NewJWTAuthenticator(provider, provider.Issuer(), cfg.OIDC.Audience, mapping)}, authns...)
+	}
+
+	m, err := metrics.New(
+		collectors.NewDBStatsCollector(conn, cfg.Database.Name),
+		repository.QueryDuration,
+		metrics.NewTodoCollector(orgStatsRepo{orgs: orepo, stats: srepo}),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("could not register metrics")
+		return err
+	}
+
+	hc := health.New(readinessTimeout,
+		health.Check{Name: "database", Check: conn.PingContext},
+		health.Check{Name: "schema", Check: schema.check},
+	)
+
+	var limiter ratelimit.Store
+	if cfg.RateLimit.enabled() {
+		limiter = ratelimit.NewMemoryStore()
+		// requests hold no connection while they are limited, queries only
+		// taking one for their transaction, so the store shares the pool
+		if cfg.RateLimit.Store == "postgres" {
+			limiter = ratelimit.NewPostgresStore(conn)
+		}
+	}
+
+	engine, err := router(cfg, m, hc, schema, limiter, routeAuthenticators(cfg.ClientAuth, authns), trepo, srepo, urepo, krepo, mrepo)
+	if err != nil {
+		log.Error().Err(err).Msg("could not configure router")
+		return err
+	}
+
+	addr := net.JoinHostPort(cfg.Application.Addr, cfg.Application.Port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Error().Err(err).Msg("could not listen")
+		return err
+	}
+
+	listeners := []listener{{
+		srv: &http.Server{
+			Handler:           engine,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		ln: ln,
+	}}
+
+	var certs *tlsconfig.CertReloader
+	if cfg.TLS.Enabled() {
+		certs, err = tlsconfig.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			ln.Close()
+			log.Error().Err(err).Msg("could not load TLS certificate")
+			return err
+		}
+
+		tlsCfg, err := tlsconfig.New(cfg.TLS, certs)
+		if err != nil {
+			ln.Close()
+			log.Error().Err(err).Msg("could not configure TLS")
+			return err
+		}
+		listeners[0].srv.TLSConfig = tlsCfg
+
+		if cfg.TLS.RedirectPort != "" {
+			redirectLn, err := net.Listen("tcp", net.JoinHostPort(cfg.Application.Addr, cfg.TLS.RedirectPort))
+			if err != nil {
+				ln.Close()
+				log.Error().Err(err).Msg("could not listen")
+				return err
+			}
+
+			listeners = append(listeners, listener{
+				srv: &http.Server{
+					Handler:           redirect(cfg.Application.Port),
+					ReadHeaderTimeout: readHeaderTimeout,
+				},
+				ln: redirectLn,
+			})
+		}
+	}
+
+	if cfg.Metrics.Port != "" {
+		metricsAddr := net.JoinHostPort(cfg.Metrics.Addr, cfg.Metrics.Port)
+		metricsLn, err := net.Listen("tcp", metricsAddr)
+		if err != nil {
+			for _, l := range listeners {
+				l.ln.Close()
+			}
+			log.Error().Err(err).Msg("could not listen")
+			return err
+		}
+
+		listeners = append(listeners, listener{
+			srv: &http.Server{
+				Handler:           metricsRouter(m),
+				ReadHeaderTimeout: readHeaderTimeout,
+			},
+			ln: metricsLn,
+		})
+		log.Info().Str("addr", metricsAddr).Msg("serving metrics")
+	}
+
+	if cfg.Reload != nil {
+		r := &reloader{cfg: cfg, dbPassword: dbPassword, certs: certs}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-cfg.Reload:
+					r.reload(ctx)
+				}
+			}
+		}()
+	}
+
+	log.Info().Str("addr", addr).Bool("tls", cfg.TLS.Enabled()).Msg("listening")
+	return serve(parent, hc, cfg.Shutdown, listeners...)
+}
 
-	return router(trepo).Run(fmt.Sprintf(":%s", cfg.Application.Port))
+// listener is a server and the listener it serves requests from.
+type listener struct {
+	srv *http.Server
+	ln  net.Listener
 }
 
-func router(trepo repository.TodoRepo) *gin.Engine {
-	router := gin.Default()
+// serve serves requests of the listener, over TLS when the server has a TLS
+// configuration.
+func (l listener) serve() error {
+	if l.srv.TLSConfig != nil {
+		return l.srv.ServeTLS(l.ln, "", "")
+	}
+	return l.srv.Serve(l.ln)
+}
+
+// serve serves requests of the listeners until ctx is closed or one of them
+// fails. It then makes readiness fail, waits for the shutdown delay so that
+// load balancers stop sending requests, stops accepting connections and waits
+// for the requests in flight to be served, for at most the shutdown timeout.
+func serve(ctx context.Context, hc *health.Health, cfg option.Shutdown, listeners ...listener) error {
+	errc := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l listener) {
+			errc <- l.serve()
+		}(l)
+	}
+
+	select {
+	case err := <-errc:
+		log.Error().Err(err).Msg("could not serve requests")
+		for _, l := range listeners {
+			_ = l.srv.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Dur("delay", cfg.Delay).Dur("timeout", cfg.Timeout).Msg("shutting down")
+	hc.Drain()
+	time.Sleep(cfg.Delay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	var res error
+	for _, l := range listeners {
+		if err := l.srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("could not serve requests in flight before shutdown timeout")
+			_ = l.srv.Close()
+			res = err
+		}
+	}
+	if res != nil {
+		return res
+	}
+
+	log.Info().Msg("shut down")
+	return nil
+}
+
+// metricsRouter serves the metrics on the internal listener, out of reach of
+// the callers of the API.
+func metricsRouter(m *metrics.Metrics) *gin.Engine {
+	router := gin.New()
+	router.GET("/metrics", m.Handler())
+	return router
+}
+
+// redirect redirects requests to the same URL over HTTPS, on the given port.
+func redirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		u := *r.URL
+		u.Scheme = "https"
+		u.Host = host
+		http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
+	})
+}
+
+func router(
+	cfg *config,
+	m *metrics.Metrics,
+	hc *health.Health,
+	schema *schemaGuard,
+	limiter ratelimit.Store,
+	authns map[string][]auth.Authenticator,
+	trepo repository.TodoRepo,
+	srepo repository.StatsRepo,
+	urepo repository.UserRepo,
+	krepo repository.APIKeyRepo,
+	mrepo repository.MemberRepo,
+) (*gin.Engine, error) {
+	router := gin.New()
+	// client IPs are only taken from forwarding headers of trusted proxies
+	if err := router.SetTrustedProxies(cfg.RateLimit.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	// handlers pass the gin context to repositories, which find the
+	// organization in the request context
+	router.ContextWithFallback = true
+	router.Use(
+		requestid.Middleware(),
+		problem.Middleware(),
+		tracing.Middleware(),
+		accesslog.Middleware(cfg.AccessLog),
+		problem.Recovery(),
+		m.Middleware(),
+	)
+
+	router.GET("/livez", hc.Livez())
+	router.GET("/readyz", hc.Readyz())
+
+	// the API runs in the organization of the request
+	api := router.Group("/", schema.middleware(), tenant.Middleware(cfg.Tenancy.Header, cfg.Tenancy.DefaultOrg))
 
 	// default handler for unknown routes
 	router.NoRoute(func(ctx *gin.Context) {
 		ctx.String(http.StatusNotImplemented, http.StatusText(http.StatusNotImplemented))
 	})
 
-	router.GET("/todo", handler.NewGetTodosHandler(trepo))
-	router.POST("/todo", handler.NewPostTodoHandler(trepo))
+	// requests are limited by client IP before authenticating, so that
+	// guessing credentials is limited too, then authenticated ones by
+	// principal, which must belong to the organization of the request
+	var byIP, byPrincipal gin.HandlersChain
+	if limiter != nil {
+		byIP = gin.HandlersChain{ratelimit.IPMiddleware(limiter, cfg.RateLimit.Read, cfg.RateLimit.Write)}
+		byPrincipal = gin.HandlersChain{ratelimit.Middleware(limiter, cfg.RateLimit.Read, cfg.RateLimit.Write)}
+	}
+	org := auth.RequireOrg(cfg.Tenancy.DefaultOrg)
+	authenticated := func(authns []auth.Authenticator) *gin.RouterGroup {
+		handlers := append(append(gin.HandlersChain{}, byIP...), auth.Middleware(authns...), org)
+		return api.Group("/", append(handlers, byPrincipal...)...)
+	}
 
-	return router
+	public := api.Group("/", byIP...)
+	todos := authenticated(authns[routeTodo])
+	members := authenticated(authns[routeMember])
+	apikeys := authenticated(authns[routeAPIKey])
+
+	// callers can not pick the organization they join
+	public.POST("/register", tenant.Only(cfg.Tenancy.DefaultOrg), handler.NewRegisterHandler(urepo))
+	public.POST("/login", handler.NewLoginHandler(urepo, cfg.SessionTTL))
+
+	read := auth.RequireScope(auth.ScopeTodoRead)
+	write := auth.RequireScope(auth.ScopeTodoWrite)
+	admin := auth.RequireScope(auth.ScopeAdmin)
+
+	todos.GET("/todo", read, handler.NewGetTodosHandler(trepo))
+	todos.POST("/todo", write, handler.NewPostTodoHandler(trepo))
+	todos.PUT("/todo", write, handler.NewPutTodoHandler(trepo))
+	todos.GET("/archive", read, handler.NewGetArchivedTodosHandler(trepo))
+	todos.GET("/stats", read, handler.NewGetStatsHandler(srepo))
+
+	members.GET("/member", read, handler.NewGetMembersHandler(mrepo))
+	members.POST("/member", write, handler.NewPostMemberHandler(mrepo))
+	members.DELETE("/member", write, handler.NewDeleteMemberHandler(mrepo))
+	members.GET("/invitation", read, handler.NewGetInvitationsHandler(mrepo))
+	members.POST("/invitation", write, handler.NewAcceptInvitationHandler(mrepo))
+	members.DELETE("/invitation", write, handler.NewDeleteInvitationHandler(mrepo))
+
+	apikeys.GET("/apikey", admin, handler.NewGetAPIKeysHandler(krepo))
+	apikeys.POST("/apikey", admin, handler.NewPostAPIKeyHandler(krepo))
+	apikeys.DELETE("/apikey", admin, handler.NewDeleteAPIKeyHandler(krepo))
+
+	return router, nil
+}
+
+// Migrate connects to the database of the options, then calls f with a
+// migrator of the embedded migrations.
+func Migrate(ctx context.Context, f func(context.Context, *database.Migrator) error, opts ...Option) error {
+	cfg, err := configure(opts...)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid configuration")
+		return err
+	}
+	if err := configureLogging(cfg); err != nil {
+		log.Error().Err(err).Msg("could not configure logging")
+		return err
+	}
+
+	migrations, err := database.LoadMigrations(database.Migrations)
+	if err != nil {
+		log.Error().Err(err).Msg("could not load migrations")
+		return err
+	}
+
+	conn, _, err := connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := f(ctx, database.NewMigrator(conn, migrations)); err != nil {
+		log.Error().Err(err).Msg("could not migrate database schema")
+		return err
+	}
+
+	return nil
+}
+
+// connect connects to the database, with the current database password for
+// every new connection.
+func connect(ctx context.Context, cfg *config) (*sql.DB, *secret.Cache, error) {
+	if err := cfg.Database.CheckTLS(); err != nil {
+		log.Error().Err(err).Msg("invalid database TLS configuration")
+		return nil, nil, err
+	}
+
+	dbPassword, err := secret.NewCache(ctx, dbPasswordOf(cfg))
+	if err != nil {
+		log.Error().Err(err).Msg("could not read database password")
+		return nil, nil, err
+	}
+
+	conn, err := database.ConnectFunc("pgx", func(ctx context.Context) (string, error) {
+		pg := cfg.Database
+		if pass, _ := dbPassword.Secret(ctx); pass != "" {
+			pg.Pass = pass
+		}
+		return pg.DSN(), nil
+	})
+	if err != nil {
+		log.Error().Err(err).Stringer("database", cfg.Database).Msg("could not connect to Lydia database")
+		return nil, nil, err
+	}
+
+	return conn, dbPassword, nil
+}
+
+// migrate migrates the schema of the database to the last version.
+func migrate(ctx context.Context, conn *sql.DB) error {
+	migrations, err := database.LoadMigrations(database.Migrations)
+	if err != nil {
+		log.Error().Err(err).Msg("could not load migrations")
+		return err
+	}
+
+	if err := database.NewMigrator(conn, migrations).MigrateUp(ctx); err != nil {
+		log.Error().Err(err).Msg("could not migrate database schema")
+		return err
+	}
+
+	log.Info().Int("version", len(migrations)).Msg("migrated database schema")
+	return nil
+}
+
+// Validate checks the options without starting the server.
+func Validate(opts ...Option) error {
+	_, err := configure(opts...)
+	return err
+}
+
+// configure applies the options to the default configuration, then checks
+// it.
+func configure(opts ...Option) (*config, error) {
+	cfg := &config{
+		SessionTTL: defaultSessionTTL,
+		Tenancy: option.Tenancy{
+			Header:     defaultOrgHeader,
+			DefaultOrg: database.DefaultOrg,
+		},
+		TLS: option.TLS{
+			MinVersion: tls.VersionTLS12,
+		},
+		Tracing: option.Tracing{
+			Exporter:    tracing.ExporterNone,
+			SampleRatio: 1,
+			ServiceName: serviceName,
+		},
+		Shutdown: option.Shutdown{
+			Timeout: defaultShutdownTimeout,
+		},
+		SchemaBehind: schemaBehindFail,
+	}
+
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// configureLogging applies the log level of cfg, when configured.
+func configureLogging(cfg *config) error {
+	if cfg.LogLevel == "" {
+		return nil
+	}
+
+	return option.ConfigureLogging(cfg.LogLevel)
+}
+
+// validate checks options that depend on each other.
+func validate(cfg *config) error {
+	if cfg.TLS.RedirectPort != "" && !cfg.TLS.Enabled() {
+		return errors.New("HTTP redirect requires TLS")
+	}
+	if cfg.TLS.ClientCAFile != "" && !cfg.TLS.Enabled() {
+		return errors.New("client certificates require TLS")
+	}
+
+	for group, mode := range cfg.ClientAuth.Routes {
+		if mode != routeAuthBearer && cfg.TLS.ClientCAFile == "" {
+			return fmt.Errorf("client certificate authentication of route group %s requires a client CA", group)
+		}
+		if mode != routeAuthBearer && len(cfg.ClientAuth.Rules) == 0 {
+			return fmt.Errorf("client certificate authentication of route group %s requires client certificate rules", group)
+		}
+	}
+
+	return nil
+}
+
+// routeAuthenticators returns the authenticators of each route group: the
+// bearer ones, the client certificate one, or both.
+func routeAuthenticators(cfg clientAuth, bearer []auth.Authenticator) map[string][]auth.Authenticator {
+	cert := auth.NewCertAuthenticator(cfg.Rules)
+
+	res := map[string][]auth.Authenticator{}
+	for _, group := range []string{routeTodo, routeMember, routeAPIKey} {
+		switch cfg.Routes[group] {
+		case routeAuthMTLS:
+			res[group] = []auth.Authenticator{cert}
+		case routeAuthAny:
+			res[group] = append([]auth.Authenticator{cert}, bearer...)
+		default:
+			res[group] = bearer
+		}
+	}
+
+	return res
+}
+
+// jwtKeys loads the keys verifying JSON Web Tokens.
+func jwtKeys(cfg option.JWT) (*auth.KeySet, error) {
+	keys := auth.NewKeySet()
+
+	for _, path := range cfg.KeyFiles {
+		if err := keys.AddKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range cfg.SecretFiles {
+		if err := keys.AddSecretFile(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range cfg.JWKSFiles {
+		if err := keys.AddJWKSFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
 }
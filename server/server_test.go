@@ -31,6 +31,7 @@ func TestListen(t *testing.T) {
 
 	appAddr := addr()
 	rootURL := fmt.Sprintf("http://127.0.0.1:%s", appAddr)
+	metricsAddr := addr()
 
 	t.Run("error on wrong options", func(t *testing.T) {
 		assert.Error(t, server.Listen(ctx, server.WithLogLevel("test")))
@@ -43,6 +44,7 @@ func TestListen(t *testing.T) {
 	go func() {
 		assert.NoError(t, server.Listen(ctx,
 			server.WithApplicationAddress("127.0.0.1", appAddr),
+			server.WithMetricsAddress("127.0.0.1", metricsAddr),
 			server.WithDatabase("todo", "todo", "127.0.0.1", "5432", "todo", "disable"),
 			server.WithLogLevel("debug"),
 		))
@@ -50,6 +52,91 @@ func TestListen(t *testing.T) {
 
 	assert.NoError(t, waitForServer(rootURL, serverTimeoutSeconds))
 
+	token := login(t, rootURL, "john@example.com", "password")
+
+	t.Run("201 response on registering", func(t *testing.T) {
+		payload := `{
+			"email": "test@example.com",
+			"password": "password"
+		}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/register", rootURL), bytes.NewReader([]byte(payload)))
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "test@example.com", gjson.GetBytes(body, "email").String())
+		assert.False(t, gjson.GetBytes(body, "password_hash").Exists())
+	})
+
+	t.Run("409 response on registering an existing email", func(t *testing.T) {
+		payload := `{
+			"email": "john@example.com",
+			"password": "password"
+		}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/register", rootURL), bytes.NewReader([]byte(payload)))
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("403 response on registering in another organization", func(t *testing.T) {
+		payload := `{
+			"email": "intruder@example.com",
+			"password": "password"
+		}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/register", rootURL), bytes.NewReader([]byte(payload)))
+		req.Header.Set("X-Org-ID", "a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("401 response on login with wrong password", func(t *testing.T) {
+		payload := `{
+			"email": "john@example.com",
+			"password": "wrong password"
+		}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/login", rootURL), bytes.NewReader([]byte(payload)))
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("401 response on getting todos without token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("401 response on getting todos with unknown token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer test")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
 	t.Run("501 response on non configured endpoints", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", rootURL, http.NoBody)
 
@@ -62,6 +149,7 @@ func TestListen(t *testing.T) {
 
 	t.Run("200 response on getting todos", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -75,8 +163,68 @@ func TestListen(t *testing.T) {
 		assert.True(t, validateSchema(t, "../schema/todos.json", body))
 	})
 
+	t.Run("200 response on getting todos including archived ones", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?include_archived=true", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, int64(3), gjson.GetBytes(body, "#").Int())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, validateSchema(t, "../schema/todos.json", body))
+	})
+
+	t.Run("200 response on getting archived todos", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/archive", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, int64(1), gjson.GetBytes(body, "#").Int())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, validateSchema(t, "../schema/todos.json", body))
+	})
+
+	t.Run("200 response on getting stats", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/stats?from=2023-03-01&to=2023-03-07&tz=Europe/Paris", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int64(7), gjson.GetBytes(body, "days.#").Int())
+		assert.True(t, validateSchema(t, "../schema/stats.json", body))
+	})
+
+	t.Run("400 response on getting stats with non-valid time zone", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/stats?tz=test", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("200 response on getting todo", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?id=%s", rootURL, "038863e4-2fbe-4bc3-9e38-1e62e93659f5"), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -91,6 +239,7 @@ func TestListen(t *testing.T) {
 
 	t.Run("400 response on getting todo without id", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?id=", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -101,6 +250,7 @@ func TestListen(t *testing.T) {
 
 	t.Run("400 response on getting todo without a valid uuid as id", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?id=%s", rootURL, "038863e4-2fbe-4bc3-9e38"), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -111,6 +261,7 @@ func TestListen(t *testing.T) {
 
 	t.Run("404 response on getting non existing todo", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?id=%s", rootURL, "038863e4-2fbe-4bc3-9e38-1e62e93659f6"), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -125,6 +276,7 @@ func TestListen(t *testing.T) {
 			"message": "Lorem ipsum"
 		}`
 		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/todo", rootURL), bytes.NewReader([]byte(payload)))
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -138,11 +290,133 @@ func TestListen(t *testing.T) {
 		assert.True(t, validateSchema(t, "../schema/todo.json", body))
 	})
 
+	t.Run("API keys are restricted to their scopes", func(t *testing.T) {
+		payload := `{
+			"name": "CI",
+			"scopes": ["todo:read"]
+		}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/apikey", rootURL), bytes.NewReader([]byte(payload)))
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		key := gjson.GetBytes(body, "key").String()
+		id := gjson.GetBytes(body, "id").String()
+
+		req, _ = http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		req, _ = http.NewRequest("POST", fmt.Sprintf("%s/todo", rootURL), bytes.NewReader([]byte(`{"message": "Lorem ipsum"}`)))
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		req, _ = http.NewRequest("DELETE", fmt.Sprintf("%s/apikey?id=%s", rootURL, id), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		req, _ = http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("shared lists are restricted to the role of the member", func(t *testing.T) {
+		janeToken := login(t, rootURL, "jane@example.com", "password")
+
+		do := func(token, method, path, payload string) int {
+			req, _ := http.NewRequest(method, rootURL+path, bytes.NewReader([]byte(payload)))
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			return resp.StatusCode
+		}
+
+		// Jane views the list of John
+		assert.Equal(t, http.StatusOK, do(janeToken, "GET", "/todo?owner_id=7c9e6679-7425-40de-944b-e07fc1f90ae7", ""))
+		assert.Equal(t, http.StatusOK, do(janeToken, "GET", "/todo?id=038863e4-2fbe-4bc3-9e38-1e62e93659f5", ""))
+		assert.Equal(t, http.StatusForbidden, do(janeToken, "PUT", "/todo?id=038863e4-2fbe-4bc3-9e38-1e62e93659f5", `{"message": "Jane was here"}`))
+		assert.Equal(t, http.StatusForbidden, do(janeToken, "POST", "/member?owner_id=7c9e6679-7425-40de-944b-e07fc1f90ae7", `{"email": "jane@example.com", "role": "editor"}`))
+
+		// John can not tell the todos of Jane exist
+		assert.Equal(t, http.StatusNotFound, do(token, "GET", "/todo?owner_id=9b2f4c1e-3d5a-4e8b-8f7c-1a2b3c4d5e6f", ""))
+		assert.Equal(t, http.StatusNotFound, do(token, "GET", "/todo?id=e1d2c3b4-a596-4877-8695-a4b3c2d1e0f9", ""))
+		assert.Equal(t, http.StatusNotFound, do(token, "PUT", "/todo?id=e1d2c3b4-a596-4877-8695-a4b3c2d1e0f9", `{"message": "John was here"}`))
+	})
+
+	t.Run("organizations are isolated from each other", func(t *testing.T) {
+		const acme = "a3c0e5d2-6b1f-4c8e-9d7a-2f4b6c8d0e1a"
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/login", rootURL), bytes.NewReader([]byte(`{"email": "john@example.com", "password": "password"}`)))
+		req.Header.Set("X-Org-ID", acme)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		acmeToken := gjson.GetBytes(body, "token").String()
+
+		do := func(token, org string) (int, []byte) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo?include_archived=true", rootURL), http.NoBody)
+			req.Header.Set("Authorization", "Bearer "+token)
+			if org != "" {
+				req.Header.Set("X-Org-ID", org)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			return resp.StatusCode, body
+		}
+
+		status, body := do(acmeToken, acme)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int64(1), gjson.GetBytes(body, "#").Int())
+		assert.Equal(t, "Acme", gjson.GetBytes(body, "0.message").String())
+
+		// sessions only exist in their organization
+		status, _ = do(acmeToken, "")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		status, _ = do(token, acme)
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		status, _ = do(token, "test")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
 	t.Run("400 response on adding todo with non-valid payload", func(t *testing.T) {
 		payload := `{
 			"is_done": true
 		}`
 		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/todo", rootURL), bytes.NewReader([]byte(payload)))
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
@@ -152,8 +426,76 @@ func TestListen(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
-		assert.Empty(t, body)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, int64(http.StatusBadRequest), gjson.GetBytes(body, "status").Int())
+		assert.Equal(t, resp.Header.Get("X-Request-ID"), gjson.GetBytes(body, "request_id").String())
+	})
+
+	t.Run("200 response on liveness and readiness probes", func(t *testing.T) {
+		for _, path := range []string{"livez", "readyz"} {
+			resp, err := http.Get(fmt.Sprintf("%s/%s", rootURL, path))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "ok", gjson.GetBytes(body, "status").String())
+		}
+	})
+
+	t.Run("200 response on getting metrics", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%s/metrics", metricsAddr))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), `http_requests_total{method="GET",route="/todo"`)
+		assert.Contains(t, string(body), "go_sql_open_connections")
+		assert.Contains(t, string(body), `todo_repository_query_duration_seconds_count{method="GetTodos",repository="TodoRepo"}`)
+		assert.Contains(t, string(body), `todo_todos{state="open"}`)
+	})
+
+	t.Run("does not serve metrics on the API", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/metrics", rootURL))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode)
 	})
+
+	t.Run("echoes the request ID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/todo", rootURL), http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Request-ID", "test-request-id")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "test-request-id", resp.Header.Get("X-Request-ID"))
+	})
+}
+
+// login logs the user in and returns its session token.
+func login(t *testing.T, rootURL, email, password string) string {
+	payload := fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("%s/login", rootURL), bytes.NewReader([]byte(payload)))
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	return gjson.GetBytes(body, "token").String()
 }
 
 // addr returns a random, free TCP port.
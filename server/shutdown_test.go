@@ -0,0 +1,102 @@
+package server_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/health"
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/iciantoine/todo-go-api/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	serve := func(ctx context.Context, handler http.Handler, cfg option.Shutdown) (string, chan error) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- server.Serve(ctx, &http.Server{Handler: handler}, ln, health.New(time.Second), cfg)
+		}()
+
+		return "http://" + ln.Addr().String(), errc
+	}
+
+	t.Run("serves requests in flight before shutting down", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		url, errc := serve(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			_, _ = io.WriteString(w, "done")
+		}), option.Shutdown{Timeout: 5 * time.Second})
+
+		respc := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(url)
+			if !assert.NoError(t, err) {
+				respc <- ""
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			respc <- string(body)
+		}()
+
+		<-started
+		cancel()
+
+		select {
+		case <-errc:
+			t.Fatal("shut down with a request in flight")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.Equal(t, "done", <-respc)
+		assert.NoError(t, <-errc)
+
+		_, err := http.Get(url)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails when requests in flight outlast the timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		url, errc := serve(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}), option.Shutdown{Timeout: 10 * time.Millisecond})
+
+		go func() {
+			resp, err := http.Get(url)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+
+		<-started
+		cancel()
+		assert.ErrorIs(t, <-errc, context.DeadlineExceeded)
+	})
+
+	t.Run("fails when it can not serve", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ln.Close()
+
+		err = server.Serve(context.Background(), &http.Server{}, ln, health.New(time.Second), option.Shutdown{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/model"
+	"github.com/iciantoine/todo-go-api/repository"
+	"github.com/iciantoine/todo-go-api/tenant"
+)
+
+// orgArchiveRepo archives the todos of every organization, each one in a
+// transaction of its own organization.
+type orgArchiveRepo struct {
+	orgs  repository.OrganizationRepo
+	todos repository.TodoRepo
+}
+
+// ArchiveTodos implements archive.TodoRepo.
+func (repo orgArchiveRepo) ArchiveTodos(ctx context.Context, before time.Time) (int64, error) {
+	orgs, err := repo.orgs.GetOrganizationIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not get organizations: %w", err)
+	}
+
+	var total int64
+	for _, org := range orgs {
+		n, err := repo.todos.ArchiveTodos(tenant.WithOrg(ctx, org), before)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("could not archive todos of organization %s: %w", org, err)
+		}
+	}
+
+	return total, nil
+}
+
+// orgStatsRepo counts the todos of every organization, each one in a
+// transaction of its own organization.
+type orgStatsRepo struct {
+	orgs  repository.OrganizationRepo
+	stats repository.StatsRepo
+}
+
+// CountTodos implements metrics.TodoCounter.
+func (repo orgStatsRepo) CountTodos(ctx context.Context) (model.TodoCounts, error) {
+	orgs, err := repo.orgs.GetOrganizationIDs(ctx)
+	if err != nil {
+		return model.TodoCounts{}, fmt.Errorf("could not get organizations: %w", err)
+	}
+
+	var total model.TodoCounts
+	for _, org := range orgs {
+		res, err := repo.stats.CountTodos(tenant.WithOrg(ctx, org))
+		if err != nil {
+			return total, fmt.Errorf("could not count todos of organization %s: %w", org, err)
+		}
+		total.Open += res.Open
+		total.Done += res.Done
+		total.Archived += res.Archived
+	}
+
+	return total, nil
+}
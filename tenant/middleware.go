@@ -0,0 +1,67 @@
+// Package tenant resolves the organization requests act in.
+package tenant
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type orgKey struct{}
+
+// WithOrg returns a copy of the context holding the organization.
+func WithOrg(ctx context.Context, org uuid.UUID) context.Context {
+	return context.WithValue(ctx, orgKey{}, org)
+}
+
+// OrgFrom returns the organization held by the context.
+func OrgFrom(ctx context.Context) (uuid.UUID, bool) {
+	org, ok := ctx.Value(orgKey{}).(uuid.UUID)
+	return org, ok
+}
+
+// Middleware runs the rest of the request in the organization given by the
+// header or, when the header is missing, in defaultOrg, and adds it to the
+// context logger. Requests with a non valid organization, or with none when
+// defaultOrg is uuid.Nil, are rejected. Whether the principal of the request
+// belongs to the organization is checked once authenticated, see
+// auth.RequireOrg.
+func Middleware(header string, defaultOrg uuid.UUID) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		org := defaultOrg
+		if val := ctx.GetHeader(header); val != "" {
+			var err error
+			if org, err = uuid.Parse(val); err != nil {
+				log.Ctx(ctx.Request.Context()).Warn().Err(err).Str("header", header).Msg("could not parse organization")
+				ctx.AbortWithStatus(http.StatusBadRequest)
+				return
+			}
+		}
+
+		if org == uuid.Nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		logger := log.Ctx(ctx.Request.Context()).With().Str("org", org.String()).Logger()
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(WithOrg(ctx.Request.Context(), org)))
+		ctx.Next()
+	}
+}
+
+// Only rejects with 403 the requests running in another organization than org,
+// all of them when org is uuid.Nil, such as the unauthenticated ones that must
+// not act in any organization the caller picks.
+func Only(org uuid.UUID) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if got, ok := OrgFrom(ctx.Request.Context()); !ok || got != org || org == uuid.Nil {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package tenant_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/iciantoine/todo-go-api/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultOrg := uuid.New()
+
+	serve := func(defaultOrg uuid.UUID, header string) (*httptest.ResponseRecorder, uuid.UUID) {
+		var got uuid.UUID
+
+		router := gin.New()
+		router.Use(tenant.Middleware("X-Org-ID", defaultOrg))
+		router.GET("/", func(ctx *gin.Context) {
+			got, _ = tenant.OrgFrom(ctx.Request.Context())
+			ctx.Status(http.StatusNoContent)
+		})
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", http.NoBody)
+		if header != "" {
+			req.Header.Set("X-Org-ID", header)
+		}
+		router.ServeHTTP(rr, req)
+
+		return rr, got
+	}
+
+	t.Run("runs in the organization of the header", func(t *testing.T) {
+		org := uuid.New()
+
+		rr, got := serve(defaultOrg, org.String())
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, org, got)
+	})
+
+	t.Run("runs in the default organization", func(t *testing.T) {
+		rr, got := serve(defaultOrg, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, defaultOrg, got)
+	})
+
+	t.Run("400 without organization nor default", func(t *testing.T) {
+		rr, _ := serve(uuid.Nil, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("400 on non valid organization", func(t *testing.T) {
+		rr, _ := serve(defaultOrg, "test")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultOrg := uuid.New()
+
+	serve := func(only uuid.UUID, header string) int {
+		router := gin.New()
+		router.Use(tenant.Middleware("X-Org-ID", defaultOrg), tenant.Only(only))
+		router.POST("/register", func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/register", http.NoBody)
+		if header != "" {
+			req.Header.Set("X-Org-ID", header)
+		}
+		router.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("lets requests of the organization through", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, serve(defaultOrg, ""))
+		assert.Equal(t, http.StatusCreated, serve(defaultOrg, defaultOrg.String()))
+	})
+
+	t.Run("403 on requests of another organization", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(defaultOrg, uuid.NewString()))
+	})
+
+	t.Run("403 on every request without organization", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(uuid.Nil, ""))
+	})
+}
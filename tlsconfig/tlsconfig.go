@@ -0,0 +1,203 @@
+// Package tlsconfig configures the TLS of the server.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/rs/zerolog/log"
+)
+
+// certReloadInterval is how often certificate files are checked for changes.
+const certReloadInterval = time.Second
+
+// Cipher suite policies.
+const (
+	// CiphersDefault are the cipher suites Go enables by default.
+	CiphersDefault = "default"
+	// CiphersStrict are the TLS 1.2 cipher suites with forward secrecy and
+	// authenticated encryption. TLS 1.3 cipher suites are all strict.
+	CiphersStrict = "strict"
+)
+
+// ParseVersion parses a TLS version such as "1.2".
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", version)
+	}
+}
+
+// CipherSuites returns the TLS 1.2 cipher suites of a policy, nil meaning
+// Go's defaults.
+func CipherSuites(policy string) ([]uint16, error) {
+	switch policy {
+	case CiphersDefault:
+		return nil, nil
+	case CiphersStrict:
+		return []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher suite policy: %s", policy)
+	}
+}
+
+// CertReloader serves a certificate loaded from files, reloaded when the
+// files change, so that renewed certificates are used without restart.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamp   string
+	checked time.Time
+}
+
+// NewCertReloader instantiates CertReloader with the PEM encoded certificate
+// chain and private key of the given files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= certReloadInterval {
+		r.reloadAndLog()
+	}
+
+	return r.cert, nil
+}
+
+// Use switches to the certificate of other files, such as on configuration
+// reload. The current certificate is kept when they can not be loaded.
+func (r *CertReloader) Use(certFile, keyFile string) error {
+	next := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if _, err := next.reload(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.certFile, r.keyFile = next.certFile, next.keyFile
+	r.cert, r.stamp, r.checked = next.cert, next.stamp, next.checked
+	return nil
+}
+
+func (r *CertReloader) reloadAndLog() {
+	changed, err := r.reload()
+	switch {
+	case err != nil:
+		log.Error().Err(err).Str("path", r.certFile).Msg("could not reload certificate")
+	case changed:
+		log.Info().Str("path", r.certFile).Msg("reloaded certificate")
+	}
+}
+
+// reload loads the certificate if its files changed since the last time.
+func (r *CertReloader) reload() (bool, error) {
+	r.checked = time.Now()
+
+	stamp, err := stamp(r.certFile, r.keyFile)
+	if err != nil {
+		return false, err
+	}
+	if stamp == r.stamp {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("could not load certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.stamp = stamp
+	return true, nil
+}
+
+// stamp identifies the versions of files by their modification time and
+// size.
+func stamp(paths ...string) (string, error) {
+	var res string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("could not stat certificate file: %w", err)
+		}
+		res += fmt.Sprintf("%s:%d:%d;", path, info.ModTime().UnixNano(), info.Size())
+	}
+
+	return res, nil
+}
+
+// New returns the TLS configuration of the server, serving the certificate of
+// certs, and verifying client certificates when a client CA file is
+// configured.
+func New(cfg option.TLS, certs *CertReloader) (*tls.Config, error) {
+	res := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     cfg.MinVersion,
+		CipherSuites:   cfg.CipherSuites,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pool, err := certPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// clients without certificate are left to other authentication
+		// methods, see auth.CertAuthenticator
+		res.ClientCAs = pool
+		res.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return res, nil
+}
+
+// certPool returns the PEM encoded CA certificates of the file at path.
+func certPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM encoded certificate in CA file: %s", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,239 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/iciantoine/todo-go-api/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certificate returns a PEM encoded self-signed certificate for localhost and
+// its private key.
+func certificate(t *testing.T, name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeCertificate writes a certificate and its key into files of the test
+// temporary directory, making sure their modification time changes.
+func writeCertificate(t *testing.T, certFile, keyFile string, cert, key []byte) {
+	mtime := time.Now().Add(time.Minute)
+	for path, data := range map[string][]byte{certFile: cert, keyFile: key} {
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+}
+
+// commonName returns the common name of the certificate served by r.
+func commonName(t *testing.T, r *tlsconfig.CertReloader) string {
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	setup := func(t *testing.T) (string, string) {
+		dir := t.TempDir()
+		certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+		cert, key := certificate(t, "old")
+		writeCertificate(t, certFile, keyFile, cert, key)
+
+		return certFile, keyFile
+	}
+
+	t.Run("loads the certificate", func(t *testing.T) {
+		certFile, keyFile := setup(t)
+
+		r, err := tlsconfig.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+		assert.Equal(t, "old", commonName(t, r))
+	})
+
+	t.Run("error on missing file", func(t *testing.T) {
+		certFile, _ := setup(t)
+
+		_, err := tlsconfig.NewCertReloader(certFile, filepath.Join(t.TempDir(), "missing"))
+		assert.Error(t, err)
+	})
+
+	t.Run("error on mismatching key", func(t *testing.T) {
+		certFile, keyFile := setup(t)
+		_, key := certificate(t, "other")
+		require.NoError(t, os.WriteFile(keyFile, key, 0o600))
+
+		_, err := tlsconfig.NewCertReloader(certFile, keyFile)
+		assert.Error(t, err)
+	})
+
+	t.Run("reloads changed files", func(t *testing.T) {
+		certFile, keyFile := setup(t)
+
+		r, err := tlsconfig.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+
+		cert, key := certificate(t, "new")
+		writeCertificate(t, certFile, keyFile, cert, key)
+		assert.NoError(t, r.Use(certFile, keyFile))
+
+		assert.Equal(t, "new", commonName(t, r))
+	})
+
+	t.Run("switches to other files", func(t *testing.T) {
+		certFile, keyFile := setup(t)
+
+		r, err := tlsconfig.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+
+		otherCert, otherKey := setup(t)
+		cert, key := certificate(t, "other")
+		writeCertificate(t, otherCert, otherKey, cert, key)
+
+		assert.NoError(t, r.Use(otherCert, otherKey))
+		assert.Equal(t, "other", commonName(t, r))
+
+		assert.Error(t, r.Use(filepath.Join(t.TempDir(), "missing"), otherKey))
+		assert.Equal(t, "other", commonName(t, r))
+	})
+
+	t.Run("keeps the previous certificate when reloading fails", func(t *testing.T) {
+		certFile, keyFile := setup(t)
+
+		r, err := tlsconfig.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+
+		writeCertificate(t, certFile, keyFile, []byte("test"), []byte("test"))
+		assert.Error(t, r.Use(certFile, keyFile))
+
+		assert.Equal(t, "old", commonName(t, r))
+	})
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	cert, key := certificate(t, "localhost")
+	writeCertificate(t, certFile, keyFile, cert, key)
+
+	certs, err := tlsconfig.NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	// the self-signed certificate is its own CA
+	cfg, err := tlsconfig.New(option.TLS{MinVersion: tls.VersionTLS13, ClientCAFile: certFile}, certs)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Verified-Chains", strconv.Itoa(len(r.TLS.VerifiedChains)))
+	}))
+	srv.TLS = cfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(cert))
+
+	t.Run("serves the certificate", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+		assert.Equal(t, "0", resp.Header.Get("X-Verified-Chains"))
+	})
+
+	t.Run("verifies client certificates", func(t *testing.T) {
+		clientCert, err := tls.X509KeyPair(cert, key)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{clientCert}}}}
+
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "1", resp.Header.Get("X-Verified-Chains"))
+	})
+
+	t.Run("rejects unknown client certificates", func(t *testing.T) {
+		otherCert, otherKey := certificate(t, "other")
+		clientCert, err := tls.X509KeyPair(otherCert, otherKey)
+		require.NoError(t, err)
+		// sent even though the server does not accept its issuer
+		send := func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &clientCert, nil }
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost", GetClientCertificate: send}}}
+
+		_, err = client.Get(srv.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("error on missing client CA file", func(t *testing.T) {
+		_, err := tlsconfig.New(option.TLS{ClientCAFile: filepath.Join(dir, "missing")}, certs)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects versions older than the minimum", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost", MaxVersion: tls.VersionTLS12}}}
+
+		_, err := client.Get(srv.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := tlsconfig.ParseVersion("1.3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), v)
+
+	_, err = tlsconfig.ParseVersion("1.0")
+	assert.Error(t, err)
+}
+
+func TestCipherSuites(t *testing.T) {
+	suites, err := tlsconfig.CipherSuites("default")
+	assert.NoError(t, err)
+	assert.Nil(t, suites)
+
+	suites, err = tlsconfig.CipherSuites("strict")
+	assert.NoError(t, err)
+	for _, id := range suites {
+		assert.NotContains(t, tls.InsecureCipherSuites(), id)
+	}
+
+	_, err = tlsconfig.CipherSuites("weak")
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DBTX is a database connection.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// txDB is a database connection running functions in transactions, such as
+// database.OrgDB.
+type txDB interface {
+	Tx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// DB traces the queries of a database connection with client spans, children
+// of the span of their context. Queries without span in their context, such
+// as those of background jobs, are not traced.
+type DB struct {
+	db     DBTX
+	tracer trace.Tracer
+}
+
+// NewDB instantiates DB.
+func NewDB(db DBTX) DB {
+	return DB{
+		db:     db,
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+// ExecContext implements repository.DBTX.
+func (d DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	res, err := d.db.ExecContext(ctx, query, args...)
+	record(span, err)
+
+	return res, err
+}
+
+// QueryRowContext implements repository.DBTX.
+func (d DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	row := d.db.QueryRowContext(ctx, query, args...)
+	record(span, row.Err())
+
+	return row
+}
+
+// QueryContext implements repository.DBTX.
+func (d DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	record(span, err)
+
+	return rows, err
+}
+
+// PrepareContext implements repository.DBTX.
+func (d DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	stmt, err := d.db.PrepareContext(ctx, query)
+	record(span, err)
+
+	return stmt, err
+}
+
+// Tx runs fn in a transaction of the traced connection when it runs
+// transactions, and directly otherwise.
+func (d DB) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if db, ok := d.db.(txDB); ok {
+		return db.Tx(ctx, fn)
+	}
+
+	return fn(ctx)
+}
+
+// start starts the span of a query when ctx holds a span.
+func (d DB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+
+	statement := Sanitize(query)
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+
+	return d.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			semconv.DBStatement(statement),
+		),
+	)
+}
+
+// record records the error of a query on its span.
+func record(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+var (
+	spaces  = regexp.MustCompile(`\s+`)
+	strs    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numbers = regexp.MustCompile(`([^\w$.])\d+(?:\.\d+)?\b`)
+)
+
+// Sanitize collapses the white space of a query and replaces its literals
+// with placeholders, so that no value ends up in traces. Values passed as
+// arguments are never traced.
+func Sanitize(query string) string {
+	query = strs.ReplaceAllString(query, "?")
+	query = numbers.ReplaceAllString(query, "$1?")
+	return strings.TrimSpace(spaces.ReplaceAllString(query, " "))
+}
@@ -0,0 +1,112 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/iciantoine/todo-go-api/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type stubDB struct {
+	tracing.DBTX
+	err error
+}
+
+func (db stubDB) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, db.err
+}
+
+func (db stubDB) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, db.err
+}
+
+type stubTxDB struct {
+	stubDB
+	called bool
+}
+
+func (db *stubTxDB) Tx(ctx context.Context, fn func(context.Context) error) error {
+	db.called = true
+	return fn(ctx)
+}
+
+func TestDB(t *testing.T) {
+	recorder := record(t)
+
+	t.Run("traces queries as children of the context span", func(t *testing.T) {
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		SUT := tracing.NewDB(stubDB{})
+
+		_, err := SUT.QueryContext(ctx, `
+			SELECT id
+			FROM todo
+			WHERE owner_id = $1 AND message = 'secret'
+		`)
+		assert.NoError(t, err)
+		parent.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+
+		span := spans[0]
+		assert.Equal(t, "SELECT", span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Contains(t, span.Attributes(), attribute.String("db.statement", "SELECT id FROM todo WHERE owner_id = $1 AND message = ?"))
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		defer parent.End()
+
+		_, err := tracing.NewDB(stubDB{err: errors.New("test")}).ExecContext(ctx, "DELETE FROM todo")
+		assert.Error(t, err)
+
+		spans := recorder.Ended()
+		assert.Equal(t, codes.Error, spans[len(spans)-1].Status().Code)
+	})
+
+	t.Run("does not trace queries without span", func(t *testing.T) {
+		before := len(recorder.Ended())
+
+		_, err := tracing.NewDB(stubDB{}).ExecContext(context.Background(), "DELETE FROM todo")
+		assert.NoError(t, err)
+		assert.Len(t, recorder.Ended(), before)
+	})
+
+	t.Run("runs transactions of the traced connection", func(t *testing.T) {
+		db := &stubTxDB{}
+		ran := false
+
+		err := tracing.NewDB(db).Tx(context.Background(), func(context.Context) error {
+			ran = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, db.called)
+		assert.True(t, ran)
+	})
+
+	t.Run("runs functions directly without transactions", func(t *testing.T) {
+		expected := errors.New("test")
+
+		err := tracing.NewDB(stubDB{}).Tx(context.Background(), func(context.Context) error {
+			return expected
+		})
+		assert.ErrorIs(t, err, expected)
+	})
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t,
+		"SELECT * FROM rate_limit WHERE updated_at < NOW() - INTERVAL ? AND tokens > ? AND key = $1 LIMIT ?",
+		tracing.Sanitize("SELECT *\n\tFROM rate_limit\n\tWHERE updated_at < NOW() - INTERVAL '1 day' AND tokens > 1.5 AND key = $1 LIMIT 10"),
+	)
+	assert.Equal(t, "SELECT ?", tracing.Sanitize("SELECT 'it''s'"))
+}
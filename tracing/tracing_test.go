@@ -0,0 +1,78 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iciantoine/todo-go-api/option"
+	"github.com/iciantoine/todo-go-api/tracing"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a tracer provider recording the ended spans.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, err := tracing.Setup(context.Background(), option.Tracing{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("error on unknown exporter", func(t *testing.T) {
+		_, err := tracing.Setup(context.Background(), option.Tracing{Exporter: "test"})
+		assert.Error(t, err)
+	})
+
+	t.Run("error on invalid OTLP endpoint", func(t *testing.T) {
+		_, err := tracing.Setup(context.Background(), option.Tracing{Exporter: tracing.ExporterOTLP, Endpoint: "localhost"})
+		assert.Error(t, err)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := record(t)
+
+	buf := new(bytes.Buffer)
+	logger := zerolog.New(buf)
+
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/todo/:id", func(ctx *gin.Context) {
+		log.Ctx(ctx.Request.Context()).Info().Msg("test")
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/todo/1", http.NoBody)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req.WithContext(logger.WithContext(req.Context())))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /todo/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+	assert.Equal(t, "b7ad6b7169203331", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", gjson.Get(buf.String(), "trace_id").String())
+	assert.Equal(t, span.SpanContext().SpanID().String(), gjson.Get(buf.String(), "span_id").String())
+}